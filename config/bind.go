@@ -0,0 +1,73 @@
+package config
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Binding typed value of the key, swapped atomically on every successful reload
+type Binding[T any] struct {
+	configure   Configure
+	key         string
+	value       atomic.Value
+	mu          sync.Mutex
+	unsubscribe func()
+}
+
+// Bind decode the value of key into T and keep it updated on reload
+//
+// the whole config is decoded when key is empty. the value of Load is shared
+// by readers and must not be modified. the last good value is kept when decode
+// fail on reload, e.g. validate fail.
+//
+//	redisConfig, err := config.Bind[RedisConfig](configure, "client.redis")
+//	maxIdle := redisConfig.Load().MaxIdle
+func Bind[T any](c Configure, key string) (*Binding[T], error) {
+	b := &Binding[T]{
+		configure: c,
+		key:       key,
+	}
+
+	b.unsubscribe = c.Subscribe(key, func([]Change) {
+		if err := b.update(); err != nil {
+			logErrorf("%s: bind update fail, keep the last good value. key:%s err:%v\n", packageName, key, err)
+		}
+	})
+
+	if err := b.update(); err != nil {
+		b.unsubscribe()
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// Load return the latest value, lock free
+func (b *Binding[T]) Load() *T {
+	return b.value.Load().(*T)
+}
+
+// Close stop updating the value on reload
+func (b *Binding[T]) Close() {
+	b.unsubscribe()
+}
+
+func (b *Binding[T]) update() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	v := new(T)
+	var err error
+	if b.key == "" {
+		err = b.configure.Unmarshal(v)
+	} else {
+		err = b.configure.UnmarshalKey(b.key, v)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	b.value.Store(v)
+	return nil
+}
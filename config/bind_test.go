@@ -0,0 +1,91 @@
+package config
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestBind(t *testing.T) {
+	type redisConfig struct {
+		MaxIdle int    `yaml:"max_idle" validate:"min=1"`
+		DSN     string `yaml:"dsn"`
+	}
+
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	writeFile(t, path, "client:\n  redis:\n    max_idle: 10\n    dsn: redis://127.0.0.1:6379/0\n")
+
+	c := defaultConfigure(path)
+	defer c.Close()
+	withTest()(c)
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Bind[redisConfig](c, "not_exist"); err == nil {
+		t.Errorf("Bind() error = nil, want config not exist")
+	}
+
+	b, err := Bind[redisConfig](c, "client.redis")
+	if err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+	defer b.Close()
+
+	whole, err := Bind[map[string]interface{}](c, "")
+	if err != nil {
+		t.Fatalf("Bind() error = %v", err)
+	}
+	defer whole.Close()
+
+	old := b.Load()
+	if old.MaxIdle != 10 || old.DSN != "redis://127.0.0.1:6379/0" {
+		t.Errorf("Binding.Load() = %v, want max_idle 10", old)
+	}
+
+	// concurrent readers during reload
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					_ = b.Load().MaxIdle
+				}
+			}
+		}()
+	}
+
+	writeFile(t, path, "client:\n  redis:\n    max_idle: 20\n    dsn: redis://127.0.0.1:6379/0\n")
+	c.Reload()
+	close(done)
+	wg.Wait()
+
+	if got := b.Load(); got.MaxIdle != 20 || got == old {
+		t.Errorf("Binding.Load() = %v, want max_idle 20", got)
+	}
+
+	if got := *whole.Load(); c.GetInt("client.redis.max_idle", 0) != 20 || got["client"] == nil {
+		t.Errorf("Binding.Load() = %v, want whole config", got)
+	}
+
+	// the last good value is kept when validate fail
+	writeFile(t, path, "client:\n  redis:\n    max_idle: 0\n")
+	c.Reload()
+	if got := b.Load(); got.MaxIdle != 20 {
+		t.Errorf("Binding.Load() = %v, want max_idle 20", got)
+	}
+
+	// not updated after close
+	b.Close()
+	writeFile(t, path, "client:\n  redis:\n    max_idle: 30\n")
+	c.Reload()
+	if got := b.Load(); got.MaxIdle != 20 {
+		t.Errorf("Binding.Load() = %v, want max_idle 20", got)
+	}
+}
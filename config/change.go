@@ -0,0 +1,187 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ChangeType type of key change
+type ChangeType int
+
+const (
+	// ChangeAdded key added
+	ChangeAdded ChangeType = iota + 1
+
+	// ChangeRemoved key removed
+	ChangeRemoved
+
+	// ChangeModified value of key modified
+	ChangeModified
+)
+
+// String ...
+func (c ChangeType) String() string {
+	switch c {
+	case ChangeAdded:
+		return "added"
+	case ChangeRemoved:
+		return "removed"
+	case ChangeModified:
+		return "modified"
+	default:
+		return "unknown"
+	}
+}
+
+// Change change of key between reloads
+type Change struct {
+	// Key dotted key of the leaf value, e.g. client.redis.max_idle
+	Key string
+
+	// Type ...
+	Type ChangeType
+
+	// OldValue nil when key added
+	OldValue interface{}
+
+	// NewValue nil when key removed
+	NewValue interface{}
+}
+
+// subscriber subscribe changes of keys with prefix
+type subscriber struct {
+	prefix   string
+	callback func([]Change)
+
+	// fold match prefix ignoring case, underscores and hyphens
+	fold bool
+}
+
+// subscribers ...
+type subscribers struct {
+	m      map[uint64]*subscriber
+	nextID uint64
+	mu     sync.Mutex
+}
+
+// add add subscriber and return the function to remove it
+func (s *subscribers) add(prefix string, fold bool, callback func([]Change)) func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.m == nil {
+		s.m = map[uint64]*subscriber{}
+	}
+
+	id := s.nextID
+	s.nextID++
+	s.m[id] = &subscriber{
+		prefix:   prefix,
+		callback: callback,
+		fold:     fold,
+	}
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.m, id)
+	}
+}
+
+// notify call back subscribers with the changes match their prefix
+func (s *subscribers) notify(changes []Change) {
+	if len(changes) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	subs := make([]*subscriber, 0, len(s.m))
+	for _, sub := range s.m {
+		subs = append(subs, sub)
+	}
+	s.mu.Unlock()
+
+	for _, sub := range subs {
+		matched := make([]Change, 0, len(changes))
+		for _, change := range changes {
+			if hasKeyPrefix(change.Key, sub.prefix) || (sub.fold && hasKeyPrefixFold(change.Key, sub.prefix)) {
+				matched = append(matched, change)
+			}
+		}
+
+		if len(matched) > 0 {
+			sub.callback(matched)
+		}
+	}
+}
+
+// hasKeyPrefix report whether the dotted key is prefix or under prefix
+func hasKeyPrefix(k, prefix string) bool {
+	return prefix == "" || k == prefix || strings.HasPrefix(k, prefix+".")
+}
+
+// hasKeyPrefixFold report whether the dotted key is prefix or under prefix, ignoring case, underscores and hyphens
+func hasKeyPrefixFold(k, prefix string) bool {
+	subkeys, prefixes := strings.Split(k, "."), strings.Split(prefix, ".")
+	if len(prefixes) > len(subkeys) {
+		return false
+	}
+
+	for i, p := range prefixes {
+		if foldKey(subkeys[i]) != foldKey(p) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// diffMap return the changes of leaf values between old and new, sorted by key
+func diffMap(oldData, newData map[string]interface{}) []Change {
+	oldLeaves := map[string]interface{}{}
+	flattenMap(oldData, "", oldLeaves)
+
+	newLeaves := map[string]interface{}{}
+	flattenMap(newData, "", newLeaves)
+
+	var changes []Change
+	for k, oldVal := range oldLeaves {
+		newVal, exist := newLeaves[k]
+		switch {
+		case !exist:
+			changes = append(changes, Change{Key: k, Type: ChangeRemoved, OldValue: oldVal})
+		case !reflect.DeepEqual(oldVal, newVal):
+			changes = append(changes, Change{Key: k, Type: ChangeModified, OldValue: oldVal, NewValue: newVal})
+		}
+	}
+
+	for k, newVal := range newLeaves {
+		if _, exist := oldLeaves[k]; !exist {
+			changes = append(changes, Change{Key: k, Type: ChangeAdded, NewValue: newVal})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+
+	return changes
+}
+
+// flattenMap flatten nested map into leaves by dotted key
+//
+// slices are treated as leaf values, empty map is a leaf value too.
+func flattenMap(m map[string]interface{}, prefix string, leaves map[string]interface{}) {
+	for k, v := range m {
+		key := joinKey(prefix, k)
+		sub, ok := toStringMap(v)
+		if !ok || len(sub) == 0 {
+			leaves[key] = v
+			continue
+		}
+
+		flattenMap(sub, key, leaves)
+	}
+}
@@ -0,0 +1,156 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_diffMap(t *testing.T) {
+	type args struct {
+		oldData map[string]interface{}
+		newData map[string]interface{}
+	}
+	tests := []struct {
+		name string
+		args args
+		want []Change
+	}{
+		{
+			name: "no change",
+			args: args{
+				oldData: map[string]interface{}{"a": 1},
+				newData: map[string]interface{}{"a": 1},
+			},
+			want: nil,
+		},
+		{
+			name: "changes",
+			args: args{
+				oldData: map[string]interface{}{
+					"a": 1,
+					"b": map[string]interface{}{"c": 1, "d": []interface{}{1}},
+				},
+				newData: map[string]interface{}{
+					"b": map[string]interface{}{"c": 2, "d": []interface{}{1}},
+					"e": map[string]interface{}{"f": "f"},
+				},
+			},
+			want: []Change{
+				{Key: "a", Type: ChangeRemoved, OldValue: 1},
+				{Key: "b.c", Type: ChangeModified, OldValue: 1, NewValue: 2},
+				{Key: "e.f", Type: ChangeAdded, NewValue: "f"},
+			},
+		},
+		{
+			name: "map replace scalar",
+			args: args{
+				oldData: map[string]interface{}{"a": 1},
+				newData: map[string]interface{}{"a": map[string]interface{}{"b": 1}},
+			},
+			want: []Change{
+				{Key: "a", Type: ChangeRemoved, OldValue: 1},
+				{Key: "a.b", Type: ChangeAdded, NewValue: 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffMap(tt.args.oldData, tt.args.newData); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffMap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_configureImpl_Subscribe(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := ioutil.WriteFile(path, []byte("client:\n  redis:\n    max_idle: 1\n  mysql:\n    max_idle: 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := defaultConfigure(path)
+	defer c.Close()
+	_ = c.Load()
+
+	var all, redis, unsubscribed []Change
+	c.Subscribe("", func(changes []Change) { all = append(all, changes...) })
+	c.Sub("client").Subscribe("redis", func(changes []Change) { redis = append(redis, changes...) })
+	unsubscribe := c.Subscribe("client", func(changes []Change) { unsubscribed = append(unsubscribed, changes...) })
+	unsubscribe()
+
+	if err := ioutil.WriteFile(path, []byte("client:\n  redis:\n    max_idle: 2\n  mysql:\n    max_idle: 2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c.Reload()
+
+	wantAll := []Change{
+		{Key: "client.mysql.max_idle", Type: ChangeModified, OldValue: 1, NewValue: 2},
+		{Key: "client.redis.max_idle", Type: ChangeModified, OldValue: 1, NewValue: 2},
+	}
+	if !reflect.DeepEqual(all, wantAll) {
+		t.Errorf("Subscribe() all = %v, want %v", all, wantAll)
+	}
+	if !reflect.DeepEqual(redis, wantAll[1:]) {
+		t.Errorf("Subscribe() redis = %v, want %v", redis, wantAll[1:])
+	}
+	if len(unsubscribed) != 0 {
+		t.Errorf("Subscribe() unsubscribed = %v, want empty", unsubscribed)
+	}
+}
+
+func Test_configureImpl_Subscribe_resolve(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := ioutil.WriteFile(path, []byte("client:\n  redis:\n    max_idle: 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := defaultConfigure(path)
+	defer c.Close()
+	WithCaseInsensitive()(c)
+	WithKeyAliases(map[string]string{"redis": "client.redis"})(c)
+	WithReloadValidator(func(c Configure) error {
+		if c.GetInt("Client.Redis.MaxIdle", 0) < 1 || c.GetInt("redis.max_idle", 0) < 1 {
+			return errors.New("max_idle must be positive")
+		}
+		return nil
+	})(c)
+	if err := c.Load(); err != nil {
+		t.Fatalf("configureImpl.Load() error = %v", err)
+	}
+
+	var folded, aliased, added []Change
+	c.Subscribe("client.Redis", func(changes []Change) { folded = append(folded, changes...) })
+	c.Subscribe("redis", func(changes []Change) { aliased = append(aliased, changes...) })
+	c.Subscribe("Client.MySQL", func(changes []Change) { added = append(added, changes...) })
+
+	if err := ioutil.WriteFile(path, []byte("client:\n  redis:\n    max_idle: 2\n  mysql:\n    max_idle: 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Reload(); err != nil {
+		t.Fatalf("configureImpl.Reload() error = %v", err)
+	}
+
+	want := []Change{{Key: "client.redis.max_idle", Type: ChangeModified, OldValue: 1, NewValue: 2}}
+	if !reflect.DeepEqual(folded, want) {
+		t.Errorf("Subscribe() folded = %v, want %v", folded, want)
+	}
+	if !reflect.DeepEqual(aliased, want) {
+		t.Errorf("Subscribe() aliased = %v, want %v", aliased, want)
+	}
+
+	wantAdded := []Change{{Key: "client.mysql.max_idle", Type: ChangeAdded, NewValue: 1}}
+	if !reflect.DeepEqual(added, wantAdded) {
+		t.Errorf("Subscribe() added = %v, want %v", added, wantAdded)
+	}
+
+	// validate the candidate by the folded and aliased keys
+	if err := ioutil.WriteFile(path, []byte("client:\n  redis:\n    max_idle: 0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Reload(); err == nil {
+		t.Errorf("configureImpl.Reload() error = nil, want validate error")
+	}
+}
@@ -1,127 +1,320 @@
 package config
 
 import (
+	"crypto/sha256"
 	"fmt"
-	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/fsnotify/fsnotify"
+	"github.com/santhosh-tekuri/jsonschema/v5"
 	"github.com/spf13/cast"
 
 	"github.com/wwwangxc/go-pkg/config/unmarshaler"
 )
 
 // Load load config
+//
+// unmarshaler is detected by file extension, e.g. .json, .toml, .yml, yaml is used when path has no extension.
+// WithUnmarshaler is required when the extension is unknown.
 func Load(path string, opts ...LoadOption) (Configure, error) {
 	return defaultLoader.Load(path, opts...)
 }
 
+// Unload close and evict the cached configs loaded from path, the next Load will load it again
+func Unload(path string) {
+	defaultLoader.Unload(path)
+}
+
+// Reset close and evict all cached configs
+func Reset() {
+	defaultLoader.Reset()
+}
+
 // Configure ...
 //go:generate mockgen -source=config.go -destination=mockconfig/config_mock.go -package=mockconfig
 type Configure interface {
 
 	// Unmarshal unmarshal config raw data
+	//
+	// default and validate struct tags are supported, e.g. default:"500ms" validate:"required,min=1"
+	// return *ValidationError when any field is invalid.
 	Unmarshal(interface{}) error
 
+	// UnmarshalKey unmarshal the sub tree of key
+	//
+	// struct tags of the unmarshaler format are respected, e.g. yaml:"max_idle"
+	// default and validate struct tags are supported like Unmarshal.
+	// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
+	UnmarshalKey(string, interface{}) error
+
 	// IsExist check the key exist
 	IsExist(string) bool
 
 	// Get get value by key
 	//
 	// return defaultVal, when key not exist
-	// k support key1.key2.key3
+	// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
 	Get(string, interface{}) interface{}
 
 	// GetString get string value by key
 	//
 	// return defaultVal, when key not exist
-	// k support key1.key2.key3
+	// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
 	GetString(string, string) string
 
 	// GetBool get bool value by key
 	//
 	// return defaultVal, when key not exist
-	// k support key1.key2.key3
+	// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
 	GetBool(string, bool) bool
 
 	// GetInt get int value by key
 	//
 	// return defaultVal, when key not exist
-	// k support key1.key2.key3
+	// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
 	GetInt(string, int) int
 
 	// GetInt32 get int32 value by key
 	//
 	// return defaultVal, when key not exist
-	// k support key1.key2.key3
+	// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
 	GetInt32(string, int32) int32
 
 	// GetInt64 get int64 value by key
 	//
 	// return defaultVal, when key not exist
-	// k support key1.key2.key3
+	// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
 	GetInt64(string, int64) int64
 
 	// GetUint get uint value by key
 	//
 	// return defaultVal, when key not exist
-	// k support key1.key2.key3
+	// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
 	GetUint(string, uint) uint
 
 	// GetUint32 get uint32 value by key
 	//
 	// return defaultVal, when key not exist
-	// k support key1.key2.key3
+	// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
 	GetUint32(string, uint32) uint32
 
 	// GetUint64 get uint64 value by key
 	//
 	// return defaultVal, when key not exist
-	// k support key1.key2.key3
+	// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
 	GetUint64(string, uint64) uint64
 
 	// GetFloat32 get float32 value by key
 	//
 	// return defaultVal, when key not exist
-	// k support key1.key2.key3
+	// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
 	GetFloat32(string, float32) float32
 
 	// GetFloat64 get float64 value by key
 	//
 	// return defaultVal, when key not exist
-	// k support key1.key2.key3
+	// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
 	GetFloat64(string, float64) float64
+
+	// GetDuration get time.Duration value by key
+	//
+	// return defaultVal, when key not exist
+	// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
+	// value support 500ms, 1h30m, or integer nanoseconds
+	GetDuration(string, time.Duration) time.Duration
+
+	// GetTime get time.Time value by key
+	//
+	// return defaultVal, when key not exist
+	// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
+	// value support RFC3339, 2006-01-02 15:04:05, 2006-01-02, unix timestamp, etc.
+	GetTime(string, time.Time) time.Time
+
+	// GetStringSlice get []string value by key
+	//
+	// return defaultVal, when key not exist
+	// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
+	GetStringSlice(string, []string) []string
+
+	// GetIntSlice get []int value by key
+	//
+	// return defaultVal, when key not exist
+	// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
+	GetIntSlice(string, []int) []int
+
+	// GetStringMap get map[string]interface{} value by key
+	//
+	// return defaultVal, when key not exist
+	// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
+	GetStringMap(string, map[string]interface{}) map[string]interface{}
+
+	// GetStringMapString get map[string]string value by key
+	//
+	// return defaultVal, when key not exist
+	// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
+	GetStringMapString(string, map[string]string) map[string]string
+
+	// GetSizeInBytes get size in bytes by key
+	//
+	// return defaultVal, when key not exist
+	// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
+	// value support 1024, 1kb, 10MB, 1GB, etc. (1kb = 1024 bytes)
+	GetSizeInBytes(string, uint) uint
+
+	// Sub get the view of sub tree by key
+	//
+	// the view shares data with the parent and stays current after reload,
+	// keys of the view are relative to the sub tree root.
+	// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
+	Sub(string) Configure
+
+	// Subscribe subscribe changes of keys with prefix between reloads
+	//
+	// callback receive the changes of leaf values sorted by key, keys are full dotted keys.
+	// callback is called synchronously after reload and should not block.
+	// subscribe all keys when prefix is empty, return the function to unsubscribe.
+	// prefix is resolved like the keys of Get, e.g. ignoring case by WithCaseInsensitive, old keys of WithKeyAliases.
+	Subscribe(string, func([]Change)) func()
+
+	// ReloadFailures count of reload failures
+	//
+	// the failed reload keeps the last good config active.
+	ReloadFailures() uint64
+
+	// Close stop watching and evict the config from the loader cache
+	//
+	// the data is still readable after close, but will not be reloaded.
+	Close() error
+
+	// Set set value of key at runtime, has the highest precedence
+	//
+	// values set are kept in a separate overlay and survive reloads,
+	// unless WithDiscardSetOnReload. subscribers are notified of the changes.
+	// sources are not read again, the value is merged over the config read by the last load or reload.
+	// k support key1.key2.key3, element of slice can not be set
+	Set(string, interface{}) error
+
+	// WriteTo write the effective config into file by the format
+	//
+	// format is the name of registered unmarshaler, e.g. yaml, toml,
+	// the format is detected by the file extension when empty.
+	// decrypted and interpolated values are written as they were, e.g. ENC(...), ${DB_PASSWORD}.
+	WriteTo(string, string) error
+
+	// Keys list dotted keys of leaf values under prefix in order
+	//
+	// slices are expanded by index, e.g. client.service.0.name.
+	// list all keys when prefix is empty, return nil when prefix not exist.
+	Keys(string) []string
+
+	// Dump dump the effective config as a tree annotated with the origin of each value
+	//
+	// origin is the file and line, environment variable, provider, override, set or default tag.
+	// secret values and values of keys like password, dsn, secret are redacted.
+	Dump() *Node
 }
 
 // configureImpl ...
 type configureImpl struct {
+	// reloadFailures keep 64-bit aligned for atomic operations
+	reloadFailures uint64
+
 	path            string
+	overlays        []string
+	providers       []Provider
+	envs            []*envSource
+	overrides       map[string]interface{}
 	rawData         []byte
 	unmarshaledData map[string]interface{}
 
 	rw            sync.RWMutex
+	subscribers   subscribers
 	watchCallback func(Configure)
 	unmarshaler   unmarshaler.Unmarshaler
 	watcher       *fsnotify.Watcher
+	watchDebounce time.Duration
+	pollInterval  time.Duration
+	done          chan struct{}
+	closeOnce     sync.Once
+
+	reloadValidators    []func(Configure) error
+	reloadErrorCallback func(error)
+
+	keyProvider KeyProvider
+
+	// secrets dotted keys of the secret values => original values, e.g. ENC(...)
+	secrets map[string]interface{}
+
+	// interpolated dotted keys of the interpolated values => original values, e.g. ${DB_PASSWORD}
+	interpolated map[string]interface{}
+	includes []string
+	profiles []string
+
+	// updateMu serialize reloads and sets, guard sets and layers
+	updateMu           sync.Mutex
+	sets               map[string]interface{}
+	discardSetOnReload bool
+
+	// layers data of the sources read by the last successful load or reload
+	layers []*layer
+
+	// origins dotted keys of leaf values => origins
+	origins        map[string]*Origin
+	redactPatterns []string
+	defaultsMu     sync.Mutex
+	defaults       map[string]interface{}
+
+	// schema compiled from schemaInline or schemaPath on load
+	schema       *jsonschema.Schema
+	schemaInline string
+	schemaPath   string
+
+	caseInsensitive bool
+
+	// aliases old keys => new keys
+	aliases map[string]string
 }
 
 func defaultConfigure(path string) *configureImpl {
 	c := &configureImpl{
-		path:        path,
-		unmarshaler: &unmarshaler.YAML{},
+		path:          path,
+		unmarshaler:   detectUnmarshaler(path),
+		watchDebounce: defaultWatchDebounce,
+		profiles:      profilesFromEnv(),
 	}
 
 	var err error
 	c.watcher, err = fsnotify.NewWatcher()
 	if err != nil {
-		logErrorf("%s: new file watcher fail. err:%v\n", packageName, err)
+		logErrorf("%s: new file watcher fail, fall back to polling. err:%v\n", packageName, err)
+		c.pollInterval = defaultPollInterval
 	}
 
 	return c
 }
 
+// detectUnmarshaler detect unmarshaler by file extension
+//
+// yaml is used when path has no extension, return nil when the extension is unknown.
+func detectUnmarshaler(path string) unmarshaler.Unmarshaler {
+	ext := filepath.Ext(path)
+	if ext == "" {
+		return &unmarshaler.YAML{}
+	}
+
+	return unmarshaler.GetByExtension(ext)
+}
+
 // Unmarshal unmarshal config raw data
+//
+// default and validate struct tags are supported, e.g. default:"500ms" validate:"required,min=1"
+// return *ValidationError when any field is invalid.
 func (c *configureImpl) Unmarshal(out interface{}) error {
 	if c.unmarshaler == nil {
 		return ErrUnmarshalerNotExist
@@ -130,7 +323,43 @@ func (c *configureImpl) Unmarshal(out interface{}) error {
 	c.rw.RLock()
 	defer c.rw.RUnlock()
 
-	return c.unmarshaler.Unmarshal(c.rawData, out)
+	var err error
+	if c.isSingleFile() {
+		err = c.unmarshaler.Unmarshal(c.rawData, out)
+	} else {
+		err = c.unmarshalMap(c.unmarshaledData, out)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return c.fillAndValidate(out, c.unmarshaledData, "")
+}
+
+// UnmarshalKey unmarshal the sub tree of key
+//
+// struct tags of the unmarshaler format are respected, e.g. yaml:"max_idle"
+// default and validate struct tags are supported like Unmarshal.
+// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
+func (c *configureImpl) UnmarshalKey(k string, out interface{}) error {
+	if c.unmarshaler == nil {
+		return ErrUnmarshalerNotExist
+	}
+
+	c.rw.RLock()
+	defer c.rw.RUnlock()
+
+	data, exist := c.fetch(c.unmarshaledData, k)
+	if !exist {
+		return ErrConfigNotExist
+	}
+
+	if err := c.unmarshalMap(data, out); err != nil {
+		return err
+	}
+
+	return c.fillAndValidate(out, data, k)
 }
 
 // IsExist check the key exist
@@ -142,7 +371,7 @@ func (c *configureImpl) IsExist(k string) bool {
 // Get get value by key
 //
 // return defaultVal, when key not exist
-// k support key1.key2.key3
+// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
 func (c *configureImpl) Get(k string, defaultVal interface{}) interface{} {
 	val, err := c.get(k)
 	if err != nil {
@@ -155,7 +384,7 @@ func (c *configureImpl) Get(k string, defaultVal interface{}) interface{} {
 // GetString get string value by key
 //
 // return defaultVal, when key not exist
-// k support key1.key2.key3
+// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
 func (c *configureImpl) GetString(k string, defaultVal string) string {
 	return cast.ToString(c.getWithDefaultVal(k, defaultVal))
 }
@@ -163,7 +392,7 @@ func (c *configureImpl) GetString(k string, defaultVal string) string {
 // GetBool get bool value by key
 //
 // return defaultVal, when key not exist
-// k support key1.key2.key3
+// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
 func (c *configureImpl) GetBool(k string, defaultVal bool) bool {
 	return cast.ToBool(c.getWithDefaultVal(k, defaultVal))
 }
@@ -171,7 +400,7 @@ func (c *configureImpl) GetBool(k string, defaultVal bool) bool {
 // GetInt get int value by key
 //
 // return defaultVal, when key not exist
-// k support key1.key2.key3
+// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
 func (c *configureImpl) GetInt(k string, defaultVal int) int {
 	return cast.ToInt(c.getWithDefaultVal(k, defaultVal))
 }
@@ -179,7 +408,7 @@ func (c *configureImpl) GetInt(k string, defaultVal int) int {
 // GetInt32 get int32 value by key
 //
 // return defaultVal, when key not exist
-// k support key1.key2.key3
+// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
 func (c *configureImpl) GetInt32(k string, defaultVal int32) int32 {
 	return cast.ToInt32(c.getWithDefaultVal(k, defaultVal))
 }
@@ -187,7 +416,7 @@ func (c *configureImpl) GetInt32(k string, defaultVal int32) int32 {
 // GetInt64 get int64 value by key
 //
 // return defaultVal, when key not exist
-// k support key1.key2.key3
+// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
 func (c *configureImpl) GetInt64(k string, defaultVal int64) int64 {
 	return cast.ToInt64(c.getWithDefaultVal(k, defaultVal))
 }
@@ -195,7 +424,7 @@ func (c *configureImpl) GetInt64(k string, defaultVal int64) int64 {
 // GetUint get uint value by key
 //
 // return defaultVal, when key not exist
-// k support key1.key2.key3
+// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
 func (c *configureImpl) GetUint(k string, defaultVal uint) uint {
 	return cast.ToUint(c.getWithDefaultVal(k, defaultVal))
 }
@@ -203,7 +432,7 @@ func (c *configureImpl) GetUint(k string, defaultVal uint) uint {
 // GetUint32 get uint32 value by key
 //
 // return defaultVal, when key not exist
-// k support key1.key2.key3
+// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
 func (c *configureImpl) GetUint32(k string, defaultVal uint32) uint32 {
 	return cast.ToUint32(c.getWithDefaultVal(k, defaultVal))
 }
@@ -211,7 +440,7 @@ func (c *configureImpl) GetUint32(k string, defaultVal uint32) uint32 {
 // GetUint64 get uint64 value by key
 //
 // return defaultVal, when key not exist
-// k support key1.key2.key3
+// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
 func (c *configureImpl) GetUint64(k string, defaultVal uint64) uint64 {
 	return cast.ToUint64(c.getWithDefaultVal(k, defaultVal))
 }
@@ -219,7 +448,7 @@ func (c *configureImpl) GetUint64(k string, defaultVal uint64) uint64 {
 // GetFloat32 get float32 value by key
 //
 // return defaultVal, when key not exist
-// k support key1.key2.key3
+// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
 func (c *configureImpl) GetFloat32(k string, defaultVal float32) float32 {
 	return cast.ToFloat32(c.getWithDefaultVal(k, defaultVal))
 }
@@ -227,11 +456,150 @@ func (c *configureImpl) GetFloat32(k string, defaultVal float32) float32 {
 // GetFloat64 get float64 value by key
 //
 // return defaultVal, when key not exist
-// k support key1.key2.key3
+// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
 func (c *configureImpl) GetFloat64(k string, defaultVal float64) float64 {
 	return cast.ToFloat64(c.getWithDefaultVal(k, defaultVal))
 }
 
+// GetDuration get time.Duration value by key
+//
+// return defaultVal, when key not exist
+// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
+// value support 500ms, 1h30m, or integer nanoseconds
+func (c *configureImpl) GetDuration(k string, defaultVal time.Duration) time.Duration {
+	return cast.ToDuration(c.getWithDefaultVal(k, defaultVal))
+}
+
+// GetTime get time.Time value by key
+//
+// return defaultVal, when key not exist
+// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
+// value support RFC3339, 2006-01-02 15:04:05, 2006-01-02, unix timestamp, etc.
+func (c *configureImpl) GetTime(k string, defaultVal time.Time) time.Time {
+	return cast.ToTime(c.getWithDefaultVal(k, defaultVal))
+}
+
+// GetStringSlice get []string value by key
+//
+// return defaultVal, when key not exist
+// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
+func (c *configureImpl) GetStringSlice(k string, defaultVal []string) []string {
+	return cast.ToStringSlice(c.getWithDefaultVal(k, defaultVal))
+}
+
+// GetIntSlice get []int value by key
+//
+// return defaultVal, when key not exist
+// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
+func (c *configureImpl) GetIntSlice(k string, defaultVal []int) []int {
+	return cast.ToIntSlice(c.getWithDefaultVal(k, defaultVal))
+}
+
+// GetStringMap get map[string]interface{} value by key
+//
+// return defaultVal, when key not exist
+// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
+func (c *configureImpl) GetStringMap(k string, defaultVal map[string]interface{}) map[string]interface{} {
+	return cast.ToStringMap(c.getWithDefaultVal(k, defaultVal))
+}
+
+// GetStringMapString get map[string]string value by key
+//
+// return defaultVal, when key not exist
+// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
+func (c *configureImpl) GetStringMapString(k string, defaultVal map[string]string) map[string]string {
+	return cast.ToStringMapString(c.getWithDefaultVal(k, defaultVal))
+}
+
+// GetSizeInBytes get size in bytes by key
+//
+// return defaultVal, when key not exist
+// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
+// value support 1024, 1kb, 10MB, 1GB, etc. (1kb = 1024 bytes)
+func (c *configureImpl) GetSizeInBytes(k string, defaultVal uint) uint {
+	data, err := c.get(k)
+	if err != nil {
+		return defaultVal
+	}
+
+	size, err := parseSizeInBytes(cast.ToString(data))
+	if err != nil {
+		return defaultVal
+	}
+
+	return size
+}
+
+// Sub get the view of sub tree by key
+//
+// the view shares data with the parent and stays current after reload,
+// keys of the view are relative to the sub tree root.
+// k support key1.key2.key3, slice index and selector, e.g. key1.0.key2, key1[name=x].key2
+func (c *configureImpl) Sub(k string) Configure {
+	return newSubConfigure(c, k)
+}
+
+// Subscribe subscribe changes of keys with prefix between reloads
+//
+// callback receive the changes of leaf values sorted by key, keys are full dotted keys.
+// callback is called synchronously after reload and should not block.
+// subscribe all keys when prefix is empty, return the function to unsubscribe.
+// prefix is resolved like the keys of Get, e.g. ignoring case by WithCaseInsensitive, old keys of WithKeyAliases.
+func (c *configureImpl) Subscribe(prefix string, callback func([]Change)) func() {
+	return c.subscribers.add(c.subscribeKey(prefix), c.caseInsensitive, callback)
+}
+
+// subscribeKey resolve the prefix to the dotted key changes are reported by
+//
+// the prefix not exist yet is kept as it is, old keys of aliases are replaced by the new keys.
+func (c *configureImpl) subscribeKey(prefix string) string {
+	if prefix == "" {
+		return ""
+	}
+
+	c.rw.RLock()
+	key, exist := c.resolveKey(c.unmarshaledData, prefix)
+	c.rw.RUnlock()
+	if exist {
+		return key
+	}
+
+	for old, newKey := range c.aliases {
+		if hasKeyPrefix(prefix, old) {
+			return newKey + prefix[len(old):]
+		}
+	}
+
+	return prefix
+}
+
+// ReloadFailures count of reload failures
+//
+// the failed reload keeps the last good config active.
+func (c *configureImpl) ReloadFailures() uint64 {
+	return atomic.LoadUint64(&c.reloadFailures)
+}
+
+// Close stop watching and evict the config from the loader cache
+//
+// the data is still readable after close, but will not be reloaded.
+func (c *configureImpl) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		if c.done != nil {
+			close(c.done)
+		}
+
+		if c.watcher != nil {
+			err = c.watcher.Close()
+		}
+
+		defaultLoader.evict(c)
+	})
+
+	return err
+}
+
 func (c *configureImpl) getWithDefaultVal(k string, defaultVal interface{}) interface{} {
 	data, err := c.get(k)
 	if err != nil {
@@ -259,6 +627,18 @@ func (c *configureImpl) getWithDefaultVal(k string, defaultVal interface{}) inte
 		_, err = cast.ToFloat64E(data)
 	case float32:
 		_, err = cast.ToFloat32E(data)
+	case time.Duration:
+		_, err = cast.ToDurationE(data)
+	case time.Time:
+		_, err = cast.ToTimeE(data)
+	case []string:
+		_, err = cast.ToStringSliceE(data)
+	case []int:
+		_, err = cast.ToIntSliceE(data)
+	case map[string]interface{}:
+		_, err = cast.ToStringMapE(data)
+	case map[string]string:
+		_, err = cast.ToStringMapStringE(data)
 	default:
 		return defaultVal
 	}
@@ -274,8 +654,7 @@ func (c *configureImpl) get(k string) (interface{}, error) {
 	c.rw.RLock()
 	defer c.rw.RUnlock()
 
-	subkeys := strings.Split(k, ".")
-	val, exist := fetchFromMap(c.unmarshaledData, subkeys)
+	val, exist := c.fetch(c.unmarshaledData, k)
 	if !exist {
 		return nil, ErrConfigNotExist
 	}
@@ -289,75 +668,366 @@ func (c *configureImpl) Load() error {
 		return ErrUnmarshalerNotExist
 	}
 
-	data, err := ioutil.ReadFile(c.path)
+	if err := c.compileSchema(); err != nil {
+		return err
+	}
+
+	layers, err := c.readLayers()
 	if err != nil {
-		return fmt.Errorf("read file fail. err:%w", err)
+		return err
 	}
 
-	unmarshaledData := map[string]interface{}{}
-	if err = c.unmarshaler.Unmarshal(data, &unmarshaledData); err != nil {
-		return fmt.Errorf("unmarshal fail. err:%w", err)
+	_, err = c.publish(layers)
+	return err
+}
+
+// Reload reload the config, the last good config is kept when reload fail
+//
+// return the error of reload, which is also counted and passed to the reload error callback.
+func (c *configureImpl) Reload() error {
+	if c.unmarshaler == nil {
+		return ErrUnmarshalerNotExist
 	}
 
-	c.rw.Lock()
-	defer c.rw.Unlock()
+	c.updateMu.Lock()
+	sets := c.sets
+	if c.discardSetOnReload {
+		c.sets = nil
+	}
 
-	c.rawData = data
-	c.unmarshaledData = unmarshaledData
+	changes, err := c.reload()
+	if err != nil {
+		c.sets = sets
+	}
+	c.updateMu.Unlock()
 
+	if err != nil {
+		c.reloadFail(err)
+		return err
+	}
+
+	c.subscribers.notify(changes)
 	return nil
 }
 
-// Reload ...
-func (c *configureImpl) Reload() {
-	if c.unmarshaler == nil {
-		return
+// reload read and publish the config, return the changes to notify
+//
+// the caller must hold updateMu.
+func (c *configureImpl) reload() ([]Change, error) {
+	layers, err := c.readLayers()
+	if err != nil {
+		return nil, err
 	}
 
-	data, err := ioutil.ReadFile(c.path)
+	return c.publish(layers)
+}
+
+// publish merge the layers with the values set at runtime, process, validate and publish the config,
+// return the changes to notify
+//
+// the caller must hold updateMu.
+func (c *configureImpl) publish(layers []*layer) ([]Change, error) {
+	rawData, unmarshaledData, origins, interpolated := c.merge(layers)
+	rawData, secrets, err := c.process(rawData, unmarshaledData, interpolated)
 	if err != nil {
-		logErrorf("%s: reload file fail. err:%v\n", packageName, err)
-		return
+		return nil, err
 	}
 
-	unmarshalData := map[string]interface{}{}
-	if err = c.unmarshaler.Unmarshal(data, &unmarshalData); err != nil {
-		logErrorf("%s: reload file unmarshal fail. err:%v\n", packageName, err)
-		return
+	if err = c.validate(rawData, unmarshaledData, secrets); err != nil {
+		return nil, err
 	}
 
 	c.rw.Lock()
 	defer c.rw.Unlock()
 
-	c.rawData = data
-	c.unmarshaledData = unmarshalData
+	oldData := c.unmarshaledData
+	c.rawData = rawData
+	c.unmarshaledData = unmarshaledData
+	c.secrets = secrets
+	c.origins = origins
+	c.interpolated = interpolated
+	c.layers = layers
+
+	return diffMap(oldData, unmarshaledData), nil
 }
 
-func (c *configureImpl) watch(callback func(*configureImpl)) {
-	if c.watcher == nil {
-		return
+// process decrypt secret values and resolve references to other keys in place
+//
+// raw data is dropped when any value rewritten, so the data is unmarshaled from the map.
+// the original values of references resolved are recorded into interpolated.
+func (c *configureImpl) process(rawData []byte, unmarshaledData, interpolated map[string]interface{}) ([]byte,
+	map[string]interface{}, error) {
+	secrets, err := c.decryptSecrets(unmarshaledData)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	go func() {
-		for event := range c.watcher.Events {
-			if event.Op&fsnotify.Write != fsnotify.Write {
-				logInfo("%s: ignore file event:%s. file:%s\n", packageName, event.Name, c.path)
-				continue
-			}
+	if secrets == nil {
+		secrets = map[string]interface{}{}
+	}
 
-			c.Reload()
+	resolved, err := resolveRefs(unmarshaledData, secrets, interpolated)
+	if err != nil {
+		return nil, nil, err
+	}
 
-			if callback != nil {
-				callback(c)
-			}
+	if len(secrets) > 0 || resolved {
+		rawData = nil
+	}
+
+	return rawData, secrets, nil
+}
+
+// validate validate the candidate data against json schema, and run reload validators
+func (c *configureImpl) validate(rawData []byte, unmarshaledData map[string]interface{},
+	secrets map[string]interface{}) error {
+	if err := c.validateSchema(unmarshaledData, secrets); err != nil {
+		return err
+	}
+
+	if len(c.reloadValidators) == 0 {
+		return nil
+	}
 
-			if c.watchCallback != nil {
-				go c.watchCallback(c)
-			}
+	candidate := c.snapshot(rawData, unmarshaledData, secrets)
+	for _, validator := range c.reloadValidators {
+		if err := validator(candidate); err != nil {
+			return fmt.Errorf("%s: validate fail. err:%w", packageName, err)
 		}
+	}
+
+	return nil
+}
+
+// reloadFail count the failure and surface the error, the last good config stays active
+func (c *configureImpl) reloadFail(err error) {
+	atomic.AddUint64(&c.reloadFailures, 1)
+	logErrorf("%s: reload fail, keep the last good config. err:%v\n", packageName, err)
 
-		logInfo("%s: break file watch. file:%s\n", packageName, c.path)
+	if c.reloadErrorCallback != nil {
+		c.reloadErrorCallback(err)
+	}
+}
+
+// snapshot new configure with the data, which shares sources and unmarshaler with c
+func (c *configureImpl) snapshot(rawData []byte, unmarshaledData map[string]interface{},
+	secrets map[string]interface{}) *configureImpl {
+	return &configureImpl{
+		path:            c.path,
+		overlays:        c.overlays,
+		providers:       c.providers,
+		envs:            c.envs,
+		overrides:       c.overrides,
+		rawData:         rawData,
+		unmarshaledData: unmarshaledData,
+		unmarshaler:     c.unmarshaler,
+		keyProvider:     c.keyProvider,
+		secrets:         secrets,
+		caseInsensitive: c.caseInsensitive,
+		aliases:         c.aliases,
+	}
+}
+
+// readLayers read all sources in ascending order of precedence
+//
+// the included files are recorded to be watched even if read fail.
+func (c *configureImpl) readLayers() ([]*layer, error) {
+	var includes []string
+	defer func() {
+		c.rw.Lock()
+		c.includes = includes
+		c.rw.Unlock()
 	}()
+
+	sources := c.sources()
+	layers := make([]*layer, 0, len(sources))
+	base := map[string]interface{}{}
+	for _, s := range sources {
+		raw, data, err := s.read(base)
+		if f, ok := s.(*fileSource); ok {
+			includes = append(includes, f.includes...)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		origins := map[string]*Origin{}
+		traceOrigins(origins, s, data)
+
+		var interpolated map[string]interface{}
+		if f, ok := s.(*fileSource); ok {
+			interpolated = f.interpolated
+		}
+		layers = append(layers, &layer{source: s, raw: raw, data: data, origins: origins, interpolated: interpolated})
+		base = mergeMap(base, data)
+	}
+
+	return layers, nil
+}
+
+// merge merge the layers and the values set at runtime, the layers are kept unchanged
+//
+// raw data is the raw data of the first layer, absent when any value set or any alias applied.
+// return the origins and the original values of the interpolated values too.
+func (c *configureImpl) merge(layers []*layer) ([]byte, map[string]interface{}, map[string]*Origin,
+	map[string]interface{}) {
+	var rawData []byte
+	if len(layers) > 0 {
+		rawData = layers[0].raw
+	}
+
+	unmarshaledData := map[string]interface{}{}
+	origins := map[string]*Origin{}
+	interpolated := map[string]interface{}{}
+	for _, l := range layers {
+		data, _ := copyValue(l.data).(map[string]interface{})
+		unmarshaledData = mergeMap(unmarshaledData, data)
+		for k, origin := range l.origins {
+			origins[k] = origin
+		}
+
+		overwriteInterpolated(interpolated, data, l.interpolated)
+	}
+
+	if len(c.sets) > 0 {
+		s := &overrideSource{values: c.sets, runtime: true}
+		_, setData, _ := s.read(unmarshaledData)
+		data, _ := copyValue(setData).(map[string]interface{})
+		unmarshaledData = mergeMap(unmarshaledData, data)
+		traceOrigins(origins, s, data)
+		overwriteInterpolated(interpolated, data, nil)
+		rawData = nil
+	}
+
+	if c.applyAliases(unmarshaledData, origins, interpolated) {
+		rawData = nil
+	}
+
+	return rawData, unmarshaledData, origins, interpolated
+}
+
+// sources return all sources in ascending order of precedence:
+// base file < profile files < overlay files < providers < environment variables < overrides
+//
+// the base file is absent when path is empty.
+func (c *configureImpl) sources() []source {
+	var sources []source
+	if c.path != "" {
+		sources = append(sources, c.newFileSource(c.path, false))
+		for _, profile := range c.profiles {
+			sources = append(sources, c.newFileSource(profilePath(c.path, profile), true))
+		}
+	}
+
+	for _, path := range c.overlays {
+		sources = append(sources, c.newFileSource(path, false))
+	}
+
+	for _, p := range c.providers {
+		sources = append(sources, &providerSource{provider: p, unmarshaler: c.unmarshaler})
+	}
+
+	for _, env := range c.envs {
+		sources = append(sources, env)
+	}
+
+	if len(c.overrides) > 0 {
+		sources = append(sources, &overrideSource{values: c.overrides})
+	}
+
+	return sources
+}
+
+func (c *configureImpl) newFileSource(path string, optional bool) *fileSource {
+	f := newFileSource(path, c.unmarshaler)
+	f.profiles = c.profiles
+	f.optional = optional
+	return f
+}
+
+// isSingleFile report whether the config is only read from the base file without rewritten values,
+// in which case the raw data can be unmarshaled directly.
+func (c *configureImpl) isSingleFile() bool {
+	return c.path != "" && len(c.sources()) == 1 && len(c.rawData) > 0
+}
+
+// key cache key of the configure
+//
+// the schema and the reload validators are part of the key, so loading with other validation options
+// gets a config validated by them instead of the cached one. validators are identified by their functions.
+func (c *configureImpl) key() string {
+	sources := c.sources()
+	names := make([]string, 0, len(sources))
+	for _, s := range sources {
+		names = append(names, s.name())
+	}
+
+	key := fmt.Sprintf("%s:%s", strings.Join(names, "|"), c.unmarshaler.Name())
+	switch {
+	case c.schemaPath != "":
+		key += ":schema=" + c.schemaPath
+	case c.schemaInline != "":
+		key += fmt.Sprintf(":schema=%x", sha256.Sum256([]byte(c.schemaInline)))
+	}
+
+	for _, validator := range c.reloadValidators {
+		key += fmt.Sprintf(":validator=%x", reflect.ValueOf(validator).Pointer())
+	}
+
+	return key
+}
+
+// unmarshalMap unmarshal the data into out by the format of unmarshaler
+//
+// the data has been expanded and decrypted, so it is decoded without expanding environment variables again.
+func (c *configureImpl) unmarshalMap(data interface{}, out interface{}) error {
+	m, ok := c.unmarshaler.(unmarshaler.Marshaler)
+	if !ok {
+		return ErrMarshalerNotExist
+	}
+
+	if _, ok = toStringMap(data); !ok {
+		return unmarshalValue(m, data, out)
+	}
+
+	raw, err := m.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("%s: marshal fail. err:%w", packageName, err)
+	}
+
+	return m.Decode(raw, out)
+}
+
+// unmarshalValue unmarshal the value other than map into out
+//
+// formats like toml can only marshal map at the top level, so the value is wrapped into a map
+// and decoded into a struct wrapping out, struct tags of the elements are still respected.
+func unmarshalValue(m unmarshaler.Marshaler, data interface{}, out interface{}) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("%s: out must be a non-nil pointer", packageName)
+	}
+
+	wrapper := reflect.New(reflect.StructOf([]reflect.StructField{
+		{
+			Name: "Value",
+			Type: rv.Elem().Type(),
+			Tag:  `yaml:"value" toml:"value" json:"value"`,
+		},
+	}))
+
+	raw, err := m.Marshal(map[string]interface{}{"value": data})
+	if err != nil {
+		return fmt.Errorf("%s: marshal fail. err:%w", packageName, err)
+	}
+
+	if err = m.Decode(raw, wrapper.Interface()); err != nil {
+		return err
+	}
+
+	rv.Elem().Set(wrapper.Elem().Field(0))
+	return nil
 }
 
 func fetchFromMap(m map[string]interface{}, subkeys []string) (interface{}, bool) {
@@ -374,12 +1044,39 @@ func fetchFromMap(m map[string]interface{}, subkeys []string) (interface{}, bool
 		return data, true
 	}
 
-	switch val := data.(type) {
-	case map[interface{}]interface{}:
-		return fetchFromMap(cast.ToStringMap(val), subkeys[1:])
-	case map[string]interface{}:
-		return fetchFromMap(val, subkeys[1:])
-	default:
+	val, ok := toStringMap(data)
+	if !ok {
 		return nil, false
 	}
+
+	return fetchFromMap(val, subkeys[1:])
+}
+
+// parseSizeInBytes parse size string, e.g. 1024, 1kb, 10MB, 1GB
+//
+// unit is case insensitive, 1kb = 1024 bytes, the suffix b is optional.
+func parseSizeInBytes(s string) (uint, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.TrimSuffix(s, "b")
+
+	multiplier := uint64(1)
+	switch {
+	case strings.HasSuffix(s, "k"):
+		multiplier = 1 << 10
+	case strings.HasSuffix(s, "m"):
+		multiplier = 1 << 20
+	case strings.HasSuffix(s, "g"):
+		multiplier = 1 << 30
+	}
+
+	if multiplier > 1 {
+		s = strings.TrimSpace(s[:len(s)-1])
+	}
+
+	size, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return uint(size * multiplier), nil
 }
@@ -1,14 +1,20 @@
 package config
 
 import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/wwwangxc/go-pkg/config/unmarshaler"
 )
 
 func Test_configureImpl_Unmarshal(t *testing.T) {
 	c := defaultConfigure("./testdata/config.yaml")
+	defer c.Close()
 	_ = c.Load()
 
 	type args struct {
@@ -40,8 +46,128 @@ func Test_configureImpl_Unmarshal(t *testing.T) {
 	}
 }
 
+func Test_configureImpl_UnmarshalKey(t *testing.T) {
+	c := defaultConfigure("./testdata/config.yaml")
+	defer c.Close()
+	_ = c.Load()
+
+	type subkey struct {
+		StringValue string `yaml:"string_value"`
+		IntValue    int    `yaml:"int_value"`
+	}
+
+	tests := []struct {
+		name     string
+		k        string
+		out      interface{}
+		want     interface{}
+		wantErr  bool
+		instance *configureImpl
+	}{
+		{
+			name:     "unmarshaler not exist",
+			k:        "subkey",
+			out:      &subkey{},
+			want:     &subkey{},
+			wantErr:  true,
+			instance: &configureImpl{},
+		},
+		{
+			name:     "key not exist",
+			k:        "not exist key",
+			out:      &subkey{},
+			want:     &subkey{},
+			wantErr:  true,
+			instance: c,
+		},
+		{
+			name:     "struct",
+			k:        "subkey",
+			out:      &subkey{},
+			want:     &subkey{StringValue: "string value", IntValue: -1},
+			wantErr:  false,
+			instance: c,
+		},
+		{
+			name:     "scalar",
+			k:        "subkey.string_value",
+			out:      new(string),
+			want:     func() *string { s := "string value"; return &s }(),
+			wantErr:  false,
+			instance: c,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.instance.UnmarshalKey(tt.k, tt.out); (err != nil) != tt.wantErr {
+				t.Errorf("configureImpl.UnmarshalKey() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(tt.out, tt.want) {
+				t.Errorf("configureImpl.UnmarshalKey() = %v, want %v", tt.out, tt.want)
+			}
+		})
+	}
+}
+
+func Test_configureImpl_UnmarshalKey_formats(t *testing.T) {
+	type service struct {
+		Name string `yaml:"name" toml:"name"`
+		Port int    `yaml:"port" toml:"port"`
+	}
+
+	tests := []struct {
+		name string
+		file string
+		data string
+	}{
+		{
+			name: "yaml",
+			file: "app.yaml",
+			data: "client:\n  timeout: 100\n  service:\n    - name: redis\n      port: 6379\n    - name: mysql\n      port: 3306\n",
+		},
+		{
+			name: "toml",
+			file: "app.toml",
+			data: "[client]\ntimeout = 100\n[[client.service]]\nname = \"redis\"\nport = 6379\n" +
+				"[[client.service]]\nname = \"mysql\"\nport = 3306\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			writeFile(t, path, tt.data)
+
+			c := defaultConfigure(path)
+			defer c.Close()
+			withTest()(c)
+			WithOverride("client.debug", true)(c)
+			if err := c.Load(); err != nil {
+				t.Fatalf("configureImpl.Load() error = %v", err)
+			}
+
+			var timeout int
+			if err := c.UnmarshalKey("client.timeout", &timeout); err != nil || timeout != 100 {
+				t.Errorf("configureImpl.UnmarshalKey() = %v, error = %v, want 100", timeout, err)
+			}
+
+			var services []service
+			want := []service{{Name: "redis", Port: 6379}, {Name: "mysql", Port: 3306}}
+			if err := c.UnmarshalKey("client.service", &services); err != nil || !reflect.DeepEqual(services, want) {
+				t.Errorf("configureImpl.UnmarshalKey() = %v, error = %v, want %v", services, err, want)
+			}
+
+			var svc service
+			if err := c.UnmarshalKey("client.service.1", &svc); err != nil || !reflect.DeepEqual(svc, want[1]) {
+				t.Errorf("configureImpl.UnmarshalKey() = %v, error = %v, want %v", svc, err, want[1])
+			}
+		})
+	}
+}
+
 func Test_configureImpl_IsExist(t *testing.T) {
 	c := defaultConfigure("./testdata/config.yaml")
+	defer c.Close()
 	_ = c.Load()
 
 	type args struct {
@@ -92,6 +218,7 @@ func Test_configureImpl_IsExist(t *testing.T) {
 
 func Test_configureImpl_Get(t *testing.T) {
 	c := defaultConfigure("./testdata/config.yaml")
+	defer c.Close()
 	_ = c.Load()
 
 	type args struct {
@@ -147,6 +274,7 @@ func Test_configureImpl_Get(t *testing.T) {
 
 func Test_configureImpl_GetString(t *testing.T) {
 	c := defaultConfigure("./testdata/config.yaml")
+	defer c.Close()
 	_ = c.Load()
 
 	type args struct {
@@ -202,6 +330,7 @@ func Test_configureImpl_GetString(t *testing.T) {
 
 func Test_configureImpl_GetBool(t *testing.T) {
 	c := defaultConfigure("./testdata/config.yaml")
+	defer c.Close()
 	_ = c.Load()
 
 	type args struct {
@@ -257,6 +386,7 @@ func Test_configureImpl_GetBool(t *testing.T) {
 
 func Test_configureImpl_GetInt(t *testing.T) {
 	c := defaultConfigure("./testdata/config.yaml")
+	defer c.Close()
 	_ = c.Load()
 
 	type args struct {
@@ -312,6 +442,7 @@ func Test_configureImpl_GetInt(t *testing.T) {
 
 func Test_configureImpl_GetInt32(t *testing.T) {
 	c := defaultConfigure("./testdata/config.yaml")
+	defer c.Close()
 	_ = c.Load()
 
 	type args struct {
@@ -367,6 +498,7 @@ func Test_configureImpl_GetInt32(t *testing.T) {
 
 func Test_configureImpl_GetInt64(t *testing.T) {
 	c := defaultConfigure("./testdata/config.yaml")
+	defer c.Close()
 	_ = c.Load()
 
 	type args struct {
@@ -422,6 +554,7 @@ func Test_configureImpl_GetInt64(t *testing.T) {
 
 func Test_configureImpl_GetUint(t *testing.T) {
 	c := defaultConfigure("./testdata/config.yaml")
+	defer c.Close()
 	_ = c.Load()
 
 	type args struct {
@@ -477,6 +610,7 @@ func Test_configureImpl_GetUint(t *testing.T) {
 
 func Test_configureImpl_GetUint32(t *testing.T) {
 	c := defaultConfigure("./testdata/config.yaml")
+	defer c.Close()
 	_ = c.Load()
 
 	type args struct {
@@ -532,6 +666,7 @@ func Test_configureImpl_GetUint32(t *testing.T) {
 
 func Test_configureImpl_GetUint64(t *testing.T) {
 	c := defaultConfigure("./testdata/config.yaml")
+	defer c.Close()
 	_ = c.Load()
 
 	type args struct {
@@ -587,6 +722,7 @@ func Test_configureImpl_GetUint64(t *testing.T) {
 
 func Test_configureImpl_GetFloat32(t *testing.T) {
 	c := defaultConfigure("./testdata/config.yaml")
+	defer c.Close()
 	_ = c.Load()
 
 	type args struct {
@@ -642,6 +778,7 @@ func Test_configureImpl_GetFloat32(t *testing.T) {
 
 func Test_configureImpl_GetFloat64(t *testing.T) {
 	c := defaultConfigure("./testdata/config.yaml")
+	defer c.Close()
 	_ = c.Load()
 
 	type args struct {
@@ -695,8 +832,345 @@ func Test_configureImpl_GetFloat64(t *testing.T) {
 	}
 }
 
+func Test_configureImpl_GetDuration(t *testing.T) {
+	c := defaultConfigure("./testdata/config.yaml")
+	defer c.Close()
+	_ = c.Load()
+
+	type args struct {
+		k          string
+		defaultVal time.Duration
+	}
+	tests := []struct {
+		name string
+		args args
+		want time.Duration
+	}{
+		{
+			name: "return default value",
+			args: args{
+				k:          "not exist key",
+				defaultVal: time.Second,
+			},
+			want: time.Second,
+		},
+		{
+			name: "value convert fail",
+			args: args{
+				k:          "string_value",
+				defaultVal: time.Second,
+			},
+			want: time.Second,
+		},
+		{
+			name: "normal",
+			args: args{
+				k:          "duration_value",
+				defaultVal: time.Second,
+			},
+			want: 500 * time.Millisecond,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.GetDuration(tt.args.k, tt.args.defaultVal); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("configureImpl.GetDuration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_configureImpl_GetTime(t *testing.T) {
+	c := defaultConfigure("./testdata/config.yaml")
+	defer c.Close()
+	_ = c.Load()
+
+	type args struct {
+		k          string
+		defaultVal time.Time
+	}
+	tests := []struct {
+		name string
+		args args
+		want time.Time
+	}{
+		{
+			name: "return default value",
+			args: args{
+				k:          "not exist key",
+				defaultVal: time.Unix(0, 0),
+			},
+			want: time.Unix(0, 0),
+		},
+		{
+			name: "value convert fail",
+			args: args{
+				k:          "string_value",
+				defaultVal: time.Unix(0, 0),
+			},
+			want: time.Unix(0, 0),
+		},
+		{
+			name: "normal",
+			args: args{
+				k:          "time_value",
+				defaultVal: time.Unix(0, 0),
+			},
+			want: time.Date(2022, 1, 2, 15, 4, 5, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.GetTime(tt.args.k, tt.args.defaultVal); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("configureImpl.GetTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_configureImpl_GetStringSlice(t *testing.T) {
+	c := defaultConfigure("./testdata/config.yaml")
+	defer c.Close()
+	_ = c.Load()
+
+	type args struct {
+		k          string
+		defaultVal []string
+	}
+	tests := []struct {
+		name string
+		args args
+		want []string
+	}{
+		{
+			name: "return default value",
+			args: args{
+				k:          "not exist key",
+				defaultVal: []string{"default"},
+			},
+			want: []string{"default"},
+		},
+		{
+			name: "value convert fail",
+			args: args{
+				k:          "subkey",
+				defaultVal: []string{"default"},
+			},
+			want: []string{"default"},
+		},
+		{
+			name: "normal",
+			args: args{
+				k:          "string_slice_value",
+				defaultVal: []string{"default"},
+			},
+			want: []string{"a", "b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.GetStringSlice(tt.args.k, tt.args.defaultVal); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("configureImpl.GetStringSlice() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_configureImpl_GetIntSlice(t *testing.T) {
+	c := defaultConfigure("./testdata/config.yaml")
+	defer c.Close()
+	_ = c.Load()
+
+	type args struct {
+		k          string
+		defaultVal []int
+	}
+	tests := []struct {
+		name string
+		args args
+		want []int
+	}{
+		{
+			name: "return default value",
+			args: args{
+				k:          "not exist key",
+				defaultVal: []int{999},
+			},
+			want: []int{999},
+		},
+		{
+			name: "value convert fail",
+			args: args{
+				k:          "string_value",
+				defaultVal: []int{999},
+			},
+			want: []int{999},
+		},
+		{
+			name: "normal",
+			args: args{
+				k:          "int_slice_value",
+				defaultVal: []int{999},
+			},
+			want: []int{1, 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.GetIntSlice(tt.args.k, tt.args.defaultVal); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("configureImpl.GetIntSlice() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_configureImpl_GetStringMap(t *testing.T) {
+	c := defaultConfigure("./testdata/config.yaml")
+	defer c.Close()
+	_ = c.Load()
+
+	type args struct {
+		k          string
+		defaultVal map[string]interface{}
+	}
+	tests := []struct {
+		name string
+		args args
+		want map[string]interface{}
+	}{
+		{
+			name: "return default value",
+			args: args{
+				k:          "not exist key",
+				defaultVal: map[string]interface{}{},
+			},
+			want: map[string]interface{}{},
+		},
+		{
+			name: "value convert fail",
+			args: args{
+				k:          "string_value",
+				defaultVal: map[string]interface{}{},
+			},
+			want: map[string]interface{}{},
+		},
+		{
+			name: "normal",
+			args: args{
+				k:          "string_map_value",
+				defaultVal: map[string]interface{}{},
+			},
+			want: map[string]interface{}{"a": 1, "b": "b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.GetStringMap(tt.args.k, tt.args.defaultVal); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("configureImpl.GetStringMap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_configureImpl_GetStringMapString(t *testing.T) {
+	c := defaultConfigure("./testdata/config.yaml")
+	defer c.Close()
+	_ = c.Load()
+
+	type args struct {
+		k          string
+		defaultVal map[string]string
+	}
+	tests := []struct {
+		name string
+		args args
+		want map[string]string
+	}{
+		{
+			name: "return default value",
+			args: args{
+				k:          "not exist key",
+				defaultVal: map[string]string{},
+			},
+			want: map[string]string{},
+		},
+		{
+			name: "value convert fail",
+			args: args{
+				k:          "string_value",
+				defaultVal: map[string]string{},
+			},
+			want: map[string]string{},
+		},
+		{
+			name: "normal",
+			args: args{
+				k:          "string_map_value",
+				defaultVal: map[string]string{},
+			},
+			want: map[string]string{"a": "1", "b": "b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.GetStringMapString(tt.args.k, tt.args.defaultVal); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("configureImpl.GetStringMapString() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_configureImpl_GetSizeInBytes(t *testing.T) {
+	c := defaultConfigure("./testdata/config.yaml")
+	defer c.Close()
+	_ = c.Load()
+
+	type args struct {
+		k          string
+		defaultVal uint
+	}
+	tests := []struct {
+		name string
+		args args
+		want uint
+	}{
+		{
+			name: "return default value",
+			args: args{
+				k:          "not exist key",
+				defaultVal: 999,
+			},
+			want: 999,
+		},
+		{
+			name: "value convert fail",
+			args: args{
+				k:          "string_value",
+				defaultVal: 999,
+			},
+			want: 999,
+		},
+		{
+			name: "normal",
+			args: args{
+				k:          "size_value",
+				defaultVal: 999,
+			},
+			want: 10 << 20,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.GetSizeInBytes(tt.args.k, tt.args.defaultVal); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("configureImpl.GetSizeInBytes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func Test_configureImpl_getWithDefaultVal(t *testing.T) {
 	c := defaultConfigure("./testdata/config.yaml")
+	defer c.Close()
 	_ = c.Load()
 
 	type args struct {
@@ -752,6 +1226,7 @@ func Test_configureImpl_getWithDefaultVal(t *testing.T) {
 
 func Test_configureImpl_get(t *testing.T) {
 	c := defaultConfigure("./testdata/config.yaml")
+	defer c.Close()
 	_ = c.Load()
 
 	type args struct {
@@ -846,3 +1321,335 @@ func Test_configureImpl_load(t *testing.T) {
 		})
 	}
 }
+
+func Test_parseSizeInBytes(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    uint
+		wantErr bool
+	}{
+		{name: "bytes", s: "1024", want: 1024},
+		{name: "kb", s: "1kb", want: 1 << 10},
+		{name: "MB", s: "10 MB", want: 10 << 20},
+		{name: "g", s: "1g", want: 1 << 30},
+		{name: "invalid", s: "abc", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSizeInBytes(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseSizeInBytes() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("parseSizeInBytes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_configureImpl_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := ioutil.WriteFile(path, []byte("max_idle: 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var reloadErrs []error
+	c := defaultConfigure(path)
+	defer c.Close()
+	WithReloadValidator(func(c Configure) error {
+		if c.GetInt("max_idle", 0) < 1 {
+			return errors.New("max_idle must be positive")
+		}
+		return nil
+	})(c)
+	WithReloadErrorCallback(func(err error) { reloadErrs = append(reloadErrs, err) })(c)
+	if err := c.Load(); err != nil {
+		t.Errorf("configureImpl.Load() error = %v", err)
+		return
+	}
+
+	tests := []struct {
+		name         string
+		data         string
+		want         int
+		wantFailures uint64
+		wantErr      bool
+	}{
+		{
+			name:         "normal",
+			data:         "max_idle: 2\n",
+			want:         2,
+			wantFailures: 0,
+		},
+		{
+			name:         "validate fail",
+			data:         "max_idle: 0\n",
+			want:         2,
+			wantFailures: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "unmarshal fail",
+			data:         "asdf",
+			want:         2,
+			wantFailures: 2,
+			wantErr:      true,
+		},
+		{
+			name:         "recover",
+			data:         "max_idle: 3\n",
+			want:         3,
+			wantFailures: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ioutil.WriteFile(path, []byte(tt.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := c.Reload(); (err != nil) != tt.wantErr {
+				t.Errorf("configureImpl.Reload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := c.GetInt("max_idle", 0); got != tt.want {
+				t.Errorf("configureImpl.GetInt() = %v, want %v", got, tt.want)
+			}
+			if got := c.ReloadFailures(); got != tt.wantFailures {
+				t.Errorf("configureImpl.ReloadFailures() = %v, want %v", got, tt.wantFailures)
+			}
+			if got := uint64(len(reloadErrs)); got != tt.wantFailures {
+				t.Errorf("reload error callback count = %v, want %v", got, tt.wantFailures)
+			}
+		})
+	}
+
+	if err := ioutil.WriteFile(path, []byte("max_idle: 0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Load(); err == nil {
+		t.Errorf("configureImpl.Load() error = %v, wantErr %v", err, true)
+	}
+}
+
+func Test_configureImpl_formats(t *testing.T) {
+	os.Setenv("CONFIG_FORMAT_ENV", "env value")
+	defer os.Unsetenv("CONFIG_FORMAT_ENV")
+
+	type subkey struct {
+		IntValue int `yaml:"int_value"`
+	}
+
+	type appConfig struct {
+		StringValue string `yaml:"string_value"`
+		IntValue    int    `yaml:"int_value"`
+		BoolValue   bool   `yaml:"bool_value"`
+		EnvValue    string `yaml:"env_value"`
+		Subkey      subkey `yaml:"subkey"`
+	}
+
+	want := &appConfig{
+		StringValue: "string value",
+		IntValue:    -1,
+		BoolValue:   true,
+		EnvValue:    "env value",
+		Subkey:      subkey{IntValue: -1},
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		unmarshaler string
+	}{
+		{
+			name:        "hcl",
+			path:        "./testdata/config.hcl",
+			unmarshaler: "hcl",
+		},
+		{
+			name:        "ini",
+			path:        "./testdata/config.ini",
+			unmarshaler: "ini",
+		},
+		{
+			name:        "dotenv",
+			path:        "./testdata/config.env",
+			unmarshaler: "dotenv",
+		},
+		{
+			name:        "properties",
+			path:        "./testdata/config.properties",
+			unmarshaler: "properties",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := defaultConfigure(tt.path)
+			defer c.Close()
+			WithUnmarshaler(tt.unmarshaler)(c)
+			if err := c.Load(); err != nil {
+				t.Fatalf("configureImpl.Load() error = %v", err)
+			}
+
+			if got := c.GetInt("subkey.int_value", 0); got != -1 {
+				t.Errorf("configureImpl.GetInt() = %v, want -1", got)
+			}
+
+			got := &appConfig{}
+			if err := c.Unmarshal(got); err != nil || !reflect.DeepEqual(got, want) {
+				t.Errorf("configureImpl.Unmarshal() = %v, error = %v, want %v", got, err, want)
+			}
+
+			// marshal and unmarshal again
+			got = &appConfig{}
+			if err := c.unmarshalMap(c.unmarshaledData, got); err != nil || !reflect.DeepEqual(got, want) {
+				t.Errorf("configureImpl.unmarshalMap() = %v, error = %v, want %v", got, err, want)
+			}
+		})
+	}
+}
+
+func Test_configureImpl_Load_keyConflict(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        string
+		data        string
+		unmarshaler string
+	}{
+		{
+			name:        "properties value before sub keys",
+			file:        "app.properties",
+			data:        "a=1\na.b=2\n",
+			unmarshaler: "properties",
+		},
+		{
+			name:        "properties sub keys before value",
+			file:        "app.properties",
+			data:        "a.b=2\na=1\n",
+			unmarshaler: "properties",
+		},
+		{
+			name:        "dotenv value before sub keys",
+			file:        "app.env",
+			data:        "A=1\nA__B=2\n",
+			unmarshaler: "dotenv",
+		},
+		{
+			name:        "dotenv sub keys before value",
+			file:        "app.env",
+			data:        "A__B=2\nA=1\n",
+			unmarshaler: "dotenv",
+		},
+		{
+			name:        "ini",
+			file:        "app.ini",
+			data:        "a=1\n[a]\nb=2\n",
+			unmarshaler: "ini",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			writeFile(t, path, tt.data)
+
+			c := defaultConfigure(path)
+			defer c.Close()
+			withTest()(c)
+			WithUnmarshaler(tt.unmarshaler)(c)
+			if err := c.Load(); err == nil {
+				t.Errorf("configureImpl.Load() error = nil, want key conflict")
+			}
+		})
+	}
+}
+
+func Test_configureImpl_unmarshalMap(t *testing.T) {
+	_ = os.Setenv("GO_PKG_CONFIG_TEST_DB_PASSWORD", "pa${HOME}ss")
+	_ = os.Setenv("GO_PKG_CONFIG_TEST_DB_USER", "${GO_PKG_CONFIG_TEST_NOT_EXIST:?not set}")
+	defer os.Unsetenv("GO_PKG_CONFIG_TEST_DB_PASSWORD")
+	defer os.Unsetenv("GO_PKG_CONFIG_TEST_DB_USER")
+
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	writeFile(t, path, "db:\n  password: \"\"\n  user: \"\"\n")
+
+	c := defaultConfigure(path)
+	defer c.Close()
+	withTest()(c)
+	WithEnv("go_pkg_config_test")(c)
+	if err := c.Load(); err != nil {
+		t.Fatalf("configureImpl.Load() error = %v", err)
+	}
+
+	want := map[string]string{
+		"password": "pa${HOME}ss",
+		"user":     "${GO_PKG_CONFIG_TEST_NOT_EXIST:?not set}",
+	}
+
+	if got := c.GetString("db.password", ""); got != want["password"] {
+		t.Errorf("configureImpl.GetString() = %v, want %v", got, want["password"])
+	}
+
+	got := map[string]string{}
+	if err := c.UnmarshalKey("db", &got); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("configureImpl.UnmarshalKey() = %v, error = %v, want %v", got, err, want)
+	}
+
+	all := map[string]map[string]string{}
+	if err := c.Unmarshal(&all); err != nil || !reflect.DeepEqual(all["db"], want) {
+		t.Errorf("configureImpl.Unmarshal() = %v, error = %v, want %v", all, err, want)
+	}
+}
+
+func Test_detectUnmarshaler(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{
+			name: "no extension",
+			path: "path",
+			want: "yaml",
+		},
+		{
+			name: "yml",
+			path: "./app.yml",
+			want: "yaml",
+		},
+		{
+			name: "upper case",
+			path: "./app.JSON",
+			want: "json",
+		},
+		{
+			name: "toml",
+			path: "./app.toml",
+			want: "toml",
+		},
+		{
+			name: "dotenv",
+			path: "./.env",
+			want: "dotenv",
+		},
+		{
+			name: "unknown extension",
+			path: "./app.conf",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := detectUnmarshaler(tt.path)
+			if got == nil {
+				if tt.want != "" {
+					t.Errorf("detectUnmarshaler() = nil, want %v", tt.want)
+				}
+				return
+			}
+
+			if got.Name() != tt.want {
+				t.Errorf("detectUnmarshaler() = %v, want %v", got.Name(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,313 @@
+package config
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/wwwangxc/go-pkg/config/unmarshaler"
+)
+
+// OriginType type of value origin
+type OriginType string
+
+const (
+	// OriginFile value from file, including overlay, profile and included files
+	OriginFile OriginType = "file"
+
+	// OriginEnv value from environment variable
+	OriginEnv OriginType = "env"
+
+	// OriginProvider value from provider, e.g. etcd, redis
+	OriginProvider OriginType = "provider"
+
+	// OriginOverride value from WithOverride or WithOverrideArgs
+	OriginOverride OriginType = "override"
+
+	// OriginSet value set by Configure.Set
+	OriginSet OriginType = "set"
+
+	// OriginDefault value filled by default tag of the last Unmarshal or UnmarshalKey
+	OriginDefault OriginType = "default"
+)
+
+// defaultRedactPatterns values of keys containing any of the patterns are redacted
+var defaultRedactPatterns = []string{"password", "passwd", "secret", "token", "dsn"}
+
+// Origin where the value came from
+type Origin struct {
+	Type OriginType `json:"type"`
+
+	// Name absolute file path, environment variable or provider name
+	Name string `json:"name,omitempty"`
+
+	// Line line of the key in file, 0 when unknown, only yaml and json files are supported
+	Line int `json:"line,omitempty"`
+}
+
+// Node node of the effective config tree
+//
+// map and slice values are expanded into children by key or index, e.g. client.hosts.0,
+// other values are leaves.
+type Node struct {
+	// Value leaf value, redacted values are ******
+	Value interface{} `json:"value,omitempty"`
+
+	// Origin origin of the leaf value
+	Origin *Origin `json:"origin,omitempty"`
+
+	// Children children of map value by key, or children of slice value by index
+	Children map[string]*Node `json:"children,omitempty"`
+}
+
+// Dump dump the effective config as a tree annotated with the origin of each value
+//
+// origin is the file and line, environment variable, provider, override, set or default tag.
+// secret values and values of keys like password, dsn, secret are redacted,
+// see WithRedactKeys to assign the patterns.
+func (c *configureImpl) Dump() *Node {
+	return c.dumpKey("")
+}
+
+// dumpKey dump the sub tree of key, the whole config when key is empty
+//
+// return nil when key not exist.
+func (c *configureImpl) dumpKey(k string) *Node {
+	c.defaultsMu.Lock()
+	defaults := copyMap(c.defaults)
+	c.defaultsMu.Unlock()
+
+	c.rw.RLock()
+	defer c.rw.RUnlock()
+
+	root := c.dumpNode(c.unmarshaledData, "")
+	keys := make([]string, 0, len(defaults))
+	for key := range defaults {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		c.dumpDefault(root, key, defaults[key])
+	}
+
+	if k == "" {
+		return root
+	}
+
+	if key, exist := c.resolveKey(c.unmarshaledData, k); exist {
+		k = key
+	}
+
+	node := root
+	for _, subkey := range strings.Split(k, ".") {
+		if node = node.Children[subkey]; node == nil {
+			return nil
+		}
+	}
+
+	return node
+}
+
+func (c *configureImpl) dumpNode(v interface{}, key string) *Node {
+	if childKeys, children, ok := childrenOf(v); ok && (len(children) > 0 || key == "") {
+		node := &Node{Children: make(map[string]*Node, len(children))}
+		for i, k := range childKeys {
+			node.Children[k] = c.dumpNode(children[i], joinKey(key, k))
+		}
+		return node
+	}
+
+	node := &Node{Value: v, Origin: lookupOrigin(c.origins, key)}
+	if c.isRedacted(key) {
+		node.Value = redactedValue
+	}
+
+	return node
+}
+
+// dumpDefault add the default value into the tree when the key not exist
+func (c *configureImpl) dumpDefault(root *Node, key string, val interface{}) {
+	subkeys := strings.Split(key, ".")
+	node := root
+	for _, subkey := range subkeys[:len(subkeys)-1] {
+		child, exist := node.Children[subkey]
+		if !exist {
+			child = &Node{Children: map[string]*Node{}}
+			node.Children[subkey] = child
+		}
+
+		if child.Children == nil {
+			return
+		}
+		node = child
+	}
+
+	last := subkeys[len(subkeys)-1]
+	if _, exist := node.Children[last]; exist {
+		return
+	}
+
+	leaf := &Node{Value: val, Origin: &Origin{Type: OriginDefault}}
+	if c.isRedacted(key) {
+		leaf.Value = redactedValue
+	}
+	node.Children[last] = leaf
+}
+
+// isRedacted report whether the value of key is secret, or any subkey contains the redact patterns
+func (c *configureImpl) isRedacted(key string) bool {
+	for secret := range c.secrets {
+		if hasKeyPrefix(secret, key) {
+			return true
+		}
+	}
+
+	patterns := c.redactPatterns
+	if patterns == nil {
+		patterns = defaultRedactPatterns
+	}
+
+	lower := strings.ToLower(key)
+	for _, pattern := range patterns {
+		if pattern != "" && strings.Contains(lower, strings.ToLower(pattern)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// traceOrigins record the origins of leaf values of the source data
+func traceOrigins(origins map[string]*Origin, s source, data map[string]interface{}) {
+	leaves := map[string]interface{}{}
+	flattenMap(data, "", leaves)
+
+	for k := range leaves {
+		origins[k] = sourceOrigin(s, k)
+	}
+}
+
+// sourceOrigin origin of the value of key from the source
+func sourceOrigin(s source, k string) *Origin {
+	switch v := s.(type) {
+	case *fileSource:
+		if origin := lookupOrigin(v.origins, k); origin != nil {
+			return origin
+		}
+		return &Origin{Type: OriginFile, Name: absPath(v.path)}
+	case *envSource:
+		if origin := lookupOrigin(v.origins, k); origin != nil {
+			return origin
+		}
+		return &Origin{Type: OriginEnv}
+	case *providerSource:
+		return &Origin{Type: OriginProvider, Name: v.name()}
+	case *overrideSource:
+		if v.runtime {
+			return &Origin{Type: OriginSet}
+		}
+		return &Origin{Type: OriginOverride}
+	default:
+		return nil
+	}
+}
+
+// lookupOrigin origin of key, or the origin of the nearest parent key
+//
+// e.g. the value replaced by reference to a map, the value of environment variable parsed as map.
+func lookupOrigin(origins map[string]*Origin, k string) *Origin {
+	for {
+		if origin, exist := origins[k]; exist {
+			return origin
+		}
+
+		i := strings.LastIndex(k, ".")
+		if i < 0 {
+			return nil
+		}
+		k = k[:i]
+	}
+}
+
+// traceFile record the origins of leaf values of the file
+func (f *fileSource) traceFile(path string, u unmarshaler.Unmarshaler, raw []byte, data map[string]interface{}) {
+	name := absPath(path)
+	var lines map[string]int
+	if u.Name() == "yaml" || u.Name() == "json" {
+		lines = keyLines(raw)
+	}
+
+	leaves := map[string]interface{}{}
+	flattenMap(data, "", leaves)
+	for k := range leaves {
+		f.origins[k] = &Origin{Type: OriginFile, Name: name, Line: lines[k]}
+	}
+}
+
+// traceProfiles record the origins of the sections of active profiles, which will be merged over the file
+func (f *fileSource) traceProfiles(data map[string]interface{}) {
+	sections, ok := toStringMap(data[profilesKey])
+	if !ok {
+		return
+	}
+
+	for _, profile := range f.profiles {
+		section, ok := toStringMap(sections[profile])
+		if !ok {
+			continue
+		}
+
+		leaves := map[string]interface{}{}
+		flattenMap(section, "", leaves)
+		for k := range leaves {
+			if origin := lookupOrigin(f.origins, joinKey(profilesKey+"."+profile, k)); origin != nil {
+				f.origins[k] = origin
+			}
+
+			if original, exist := f.interpolated[joinKey(profilesKey+"."+profile, k)]; exist {
+				f.interpolated[k] = original
+			} else {
+				delete(f.interpolated, k)
+			}
+		}
+	}
+}
+
+// keyLines lines of dotted keys in yaml or json document, nil when parse fail
+func keyLines(raw []byte) map[string]int {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return nil
+	}
+
+	lines := map[string]int{}
+	walkKeyLines(&doc, "", lines)
+	return lines
+}
+
+func walkKeyLines(node *yaml.Node, prefix string, lines map[string]int) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, content := range node.Content {
+			walkKeyLines(content, prefix, lines)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := joinKey(prefix, node.Content[i].Value)
+			lines[key] = node.Content[i].Line
+			walkKeyLines(node.Content[i+1], key, lines)
+		}
+	}
+}
+
+func absPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+
+	return abs
+}
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_configureImpl_Dump(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.yaml")
+	common := filepath.Join(dir, "common.yaml")
+	writeFile(t, common, "client:\n  name: common\n  timeout: 1s\n")
+	writeFile(t, path, "include: common.yaml\nclient:\n  name: app\n  dsn: redis://127.0.0.1\n"+
+		"profiles:\n  prod:\n    client:\n      port: 6379\n")
+
+	c := defaultConfigure(path)
+	defer c.Close()
+	withTest()(c)
+	WithProfile("prod")(c)
+	WithOverride("debug", true)(c)
+	env := newEnvSource("APP")
+	env.environ = func() []string {
+		return []string{"APP_CLIENT_TIMEOUT=2s"}
+	}
+	c.envs = append(c.envs, env)
+
+	if err := c.Load(); err != nil {
+		t.Fatalf("configureImpl.Load() error = %v", err)
+	}
+
+	if err := c.Set("client.retry", 3); err != nil {
+		t.Fatalf("configureImpl.Set() error = %v", err)
+	}
+
+	var out struct {
+		MaxIdle int `yaml:"max_idle" default:"10"`
+	}
+	if err := c.UnmarshalKey("client", &out); err != nil {
+		t.Fatalf("configureImpl.UnmarshalKey() error = %v", err)
+	}
+
+	want := &Node{Children: map[string]*Node{
+		"debug": {Value: true, Origin: &Origin{Type: OriginOverride}},
+		"client": {Children: map[string]*Node{
+			"name":     {Value: "app", Origin: &Origin{Type: OriginFile, Name: path, Line: 3}},
+			"dsn":      {Value: redactedValue, Origin: &Origin{Type: OriginFile, Name: path, Line: 4}},
+			"timeout":  {Value: "2s", Origin: &Origin{Type: OriginEnv, Name: "APP_CLIENT_TIMEOUT"}},
+			"port":     {Value: 6379, Origin: &Origin{Type: OriginFile, Name: path, Line: 8}},
+			"retry":    {Value: 3, Origin: &Origin{Type: OriginSet}},
+			"max_idle": {Value: 10, Origin: &Origin{Type: OriginDefault}},
+		}},
+	}}
+
+	if got := c.Dump(); !reflect.DeepEqual(got, want) {
+		t.Errorf("configureImpl.Dump() = %+v, want %+v", got, want)
+	}
+
+	if got := c.Sub("client").Dump().Children["name"]; !reflect.DeepEqual(got, want.Children["client"].Children["name"]) {
+		t.Errorf("subConfigure.Dump() = %+v, want %+v", got, want.Children["client"].Children["name"])
+	}
+
+	if got := c.Sub("not_exist").Dump(); got != nil {
+		t.Errorf("subConfigure.Dump() = %+v, want nil", got)
+	}
+}
+
+func Test_configureImpl_Dump_slice(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	writeFile(t, path, "client:\n  service:\n    - name: a\n      dsn: mysql://a\n    - name: b\n      password: 123\n"+
+		"  hosts: []\n")
+
+	c := defaultConfigure(path)
+	defer c.Close()
+	withTest()(c)
+	if err := c.Load(); err != nil {
+		t.Fatalf("configureImpl.Load() error = %v", err)
+	}
+
+	origin := &Origin{Type: OriginFile, Name: path, Line: 2}
+	want := &Node{Children: map[string]*Node{
+		"client": {Children: map[string]*Node{
+			"service": {Children: map[string]*Node{
+				"0": {Children: map[string]*Node{
+					"name": {Value: "a", Origin: origin},
+					"dsn":  {Value: redactedValue, Origin: origin},
+				}},
+				"1": {Children: map[string]*Node{
+					"name":     {Value: "b", Origin: origin},
+					"password": {Value: redactedValue, Origin: origin},
+				}},
+			}},
+			"hosts": {Value: []interface{}{}, Origin: &Origin{Type: OriginFile, Name: path, Line: 7}},
+		}},
+	}}
+
+	if got := c.Dump(); !reflect.DeepEqual(got, want) {
+		t.Errorf("configureImpl.Dump() = %+v, want %+v", got, want)
+	}
+
+	if got := c.Sub("client.service[1]").Dump(); !reflect.DeepEqual(got, want.Children["client"].Children["service"].Children["1"]) {
+		t.Errorf("subConfigure.Dump() = %+v, want %+v", got, want.Children["client"].Children["service"].Children["1"])
+	}
+}
+
+func Test_configureImpl_isRedacted(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		secrets  map[string]interface{}
+		key      string
+		want     bool
+	}{
+		{
+			name: "default pattern",
+			key:  "client.redis.Password",
+			want: true,
+		},
+		{
+			name: "parent matched",
+			key:  "secrets.redis",
+			want: true,
+		},
+		{
+			name: "not matched",
+			key:  "client.redis.max_idle",
+		},
+		{
+			name:     "assigned patterns",
+			patterns: []string{"max_"},
+			key:      "client.redis.max_idle",
+			want:     true,
+		},
+		{
+			name:    "secret in slice",
+			secrets: map[string]interface{}{"client.service.0": "ENC(...)"},
+			key:     "client.service",
+			want:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &configureImpl{redactPatterns: tt.patterns, secrets: tt.secrets}
+			if got := c.isRedacted(tt.key); got != tt.want {
+				t.Errorf("configureImpl.isRedacted() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_keyLines(t *testing.T) {
+	got := keyLines([]byte("a:\n  b: 1\n  c:\n    - 1\nd: {\"e\": 2}\n"))
+	want := map[string]int{"a": 1, "a.b": 2, "a.c": 3, "d": 5, "d.e": 5}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("keyLines() = %v, want %v", got, want)
+	}
+}
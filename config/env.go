@@ -0,0 +1,161 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// EnvOption option for environment variable source
+type EnvOption func(*envSource)
+
+// WithEnvSeparator assign separator between the levels of key, default "_"
+//
+// e.g. with separator "__", APP_CLIENT__REDIS__MAX_IDLE will be mapped to client.redis.max_idle.
+func WithEnvSeparator(sep string) EnvOption {
+	return func(e *envSource) {
+		e.separator = sep
+	}
+}
+
+// WithEnvKeyMapper assign key mapper
+//
+// mapper receive the environment variable name without prefix, e.g. CLIENT_REDIS_MAX_IDLE,
+// and return the dotted key, e.g. client.redis.max_idle.
+// the environment variable will be ignored when mapper return empty string.
+func WithEnvKeyMapper(mapper func(string) string) EnvOption {
+	return func(e *envSource) {
+		e.keyMapper = mapper
+	}
+}
+
+// LoadEnv load config from environment variables with prefix
+//
+// there is no base file to match the keys, so the variables are kept as flat keys,
+// e.g. APP_CLIENT_REDIS_MAX_IDLE is kept as client_redis_max_idle when prefix is APP,
+// which can be read by client.redis.max_idle. see WithEnvSeparator to separate the levels explicitly.
+func LoadEnv(prefix string, opts ...EnvOption) (Configure, error) {
+	return defaultLoader.Load("", WithEnv(prefix, opts...))
+}
+
+// envSource environment variable source
+//
+// the environment variable name with prefix stripped is lower cased and split by separator,
+// then mapped onto the longest existing key of the lower precedence sources at each level,
+// the rest of the parts which not exist in lower sources will be joined into the last level
+// when the separator is "_", which can be read by the dotted key, e.g. client_redis_dsn by client.redis.dsn,
+// otherwise treated as one level per part.
+//
+// e.g. with prefix APP and base data client.redis.max_idle:
+//
+//	APP_CLIENT_REDIS_MAX_IDLE => client.redis.max_idle
+//	APP_CLIENT_REDIS_DSN => client.redis.dsn
+//	APP_MACHINE_ID => machine_id
+type envSource struct {
+	prefix    string
+	separator string
+	keyMapper func(string) string
+	environ   func() []string
+
+	// origins dotted keys => environment variables of the last read
+	origins map[string]*Origin
+}
+
+func newEnvSource(prefix string, opts ...EnvOption) *envSource {
+	e := &envSource{
+		prefix:    prefix,
+		separator: "_",
+		environ:   os.Environ,
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e
+}
+
+func (e *envSource) name() string {
+	return fmt.Sprintf("env:%s", e.prefix)
+}
+
+func (e *envSource) read(base map[string]interface{}) ([]byte, map[string]interface{}, error) {
+	prefix := ""
+	if e.prefix != "" {
+		prefix = strings.ToUpper(e.prefix) + "_"
+	}
+
+	envs := e.environ()
+	sort.Strings(envs)
+
+	data := map[string]interface{}{}
+	e.origins = map[string]*Origin{}
+	for _, env := range envs {
+		kv := strings.SplitN(env, "=", 2)
+		if len(kv) != 2 || !strings.HasPrefix(strings.ToUpper(kv[0]), prefix) {
+			continue
+		}
+
+		subkeys := e.subkeys(base, kv[0][len(prefix):])
+		if len(subkeys) == 0 {
+			continue
+		}
+
+		setToMap(data, subkeys, parseValue(kv[1]))
+		e.origins[strings.Join(subkeys, ".")] = &Origin{Type: OriginEnv, Name: kv[0]}
+	}
+
+	return nil, data, nil
+}
+
+func (e *envSource) subkeys(base map[string]interface{}, name string) []string {
+	if name == "" {
+		return nil
+	}
+
+	if e.keyMapper != nil {
+		k := e.keyMapper(name)
+		if k == "" {
+			return nil
+		}
+		return strings.Split(k, ".")
+	}
+
+	var parts []string
+	for _, part := range strings.Split(strings.ToLower(name), e.separator) {
+		if part == "" {
+			return nil
+		}
+		parts = append(parts, part)
+	}
+
+	return matchSubkeys(base, parts, e.separator)
+}
+
+// matchSubkeys join parts into the longest existing key of m at each level
+//
+// the rest of the parts not exist in m are joined into one level when sep is "_",
+// since "_" can not tell the separator of levels from the one in key.
+func matchSubkeys(m map[string]interface{}, parts []string, sep string) []string {
+	if len(parts) == 0 {
+		return nil
+	}
+
+	for n := len(parts); n > 0; n-- {
+		k := strings.Join(parts[:n], sep)
+		val, exist := m[k]
+		if !exist {
+			continue
+		}
+
+		sub, _ := toStringMap(val)
+		return append([]string{k}, matchSubkeys(sub, parts[n:], sep)...)
+	}
+
+	if sep == "_" {
+		return []string{strings.Join(parts, sep)}
+	}
+
+	return parts
+}
@@ -0,0 +1,173 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_envSource_read(t *testing.T) {
+	environ := func() []string {
+		return []string{
+			"APP_CLIENT_REDIS_MAX_IDLE=10",
+			"APP_CLIENT_REDIS_DSN=redis://127.0.0.1:6379",
+			"APP_MACHINE_ID=0123",
+			"APP_DEBUG=true",
+			"APP_=ignored",
+			"OTHER_KEY=ignored",
+		}
+	}
+
+	base := map[string]interface{}{
+		"client": map[string]interface{}{
+			"redis": map[string]interface{}{
+				"max_idle": 1,
+			},
+		},
+	}
+
+	tests := []struct {
+		name string
+		base map[string]interface{}
+		opts []EnvOption
+		want map[string]interface{}
+	}{
+		{
+			name: "without base",
+			opts: []EnvOption{},
+			want: map[string]interface{}{
+				"client_redis_max_idle": 10,
+				"client_redis_dsn":      "redis://127.0.0.1:6379",
+				"machine_id":            "0123",
+				"debug":                 true,
+			},
+		},
+		{
+			name: "match base",
+			base: base,
+			opts: []EnvOption{},
+			want: map[string]interface{}{
+				"client": map[string]interface{}{
+					"redis": map[string]interface{}{
+						"max_idle": 10,
+						"dsn":      "redis://127.0.0.1:6379",
+					},
+				},
+				"machine_id": "0123",
+				"debug":      true,
+			},
+		},
+		{
+			name: "key mapper",
+			opts: []EnvOption{
+				WithEnvKeyMapper(func(name string) string {
+					if name != "MACHINE_ID" {
+						return ""
+					}
+					return strings.ToLower(name)
+				}),
+			},
+			want: map[string]interface{}{
+				"machine_id": "0123",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newEnvSource("app", tt.opts...)
+			e.environ = environ
+
+			_, got, err := e.read(tt.base)
+			if err != nil {
+				t.Errorf("envSource.read() error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("envSource.read() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_envSource_separator(t *testing.T) {
+	e := newEnvSource("APP", WithEnvSeparator("__"))
+	e.environ = func() []string {
+		return []string{"APP_CLIENT__REDIS__MAX_IDLE=10"}
+	}
+
+	want := map[string]interface{}{
+		"client": map[string]interface{}{
+			"redis": map[string]interface{}{"max_idle": 10},
+		},
+	}
+
+	_, got, err := e.read(nil)
+	if err != nil {
+		t.Errorf("envSource.read() error = %v", err)
+		return
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("envSource.read() = %v, want %v", got, want)
+	}
+}
+
+func TestLoadEnv(t *testing.T) {
+	_ = os.Setenv("GO_PKG_CONFIG_TEST_SUBKEY_INT_VALUE", "-100")
+	_ = os.Setenv("GO_PKG_CONFIG_TEST_CLIENT_REDIS_MAX_IDLE", "10")
+	defer os.Unsetenv("GO_PKG_CONFIG_TEST_SUBKEY_INT_VALUE")
+	defer os.Unsetenv("GO_PKG_CONFIG_TEST_CLIENT_REDIS_MAX_IDLE")
+
+	c, err := defaultLoader.Load("./testdata/config.yaml", WithEnv("go_pkg_config_test"), withTest())
+	if err != nil {
+		t.Errorf("Load() error = %v", err)
+		return
+	}
+	defer c.Close()
+
+	if got := c.GetInt("subkey.int_value", 0); got != -100 {
+		t.Errorf("configureImpl.GetInt() = %v, want %v", got, -100)
+	}
+
+	tests := []struct {
+		name string
+		opts []EnvOption
+	}{
+		{
+			name: "default separator",
+		},
+		{
+			name: "underscore separator",
+			opts: []EnvOption{WithEnvSeparator("_")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := LoadEnv("go_pkg_config_test", tt.opts...)
+			if err != nil {
+				t.Errorf("LoadEnv() error = %v", err)
+				return
+			}
+			defer Unload("")
+
+			if got := c.GetInt("client.redis.max_idle", 0); got != 10 {
+				t.Errorf("configureImpl.GetInt() = %v, want %v", got, 10)
+			}
+
+			if got := c.Sub("client").GetInt("redis.max_idle", 0); got != 10 {
+				t.Errorf("subConfigure.GetInt() = %v, want %v", got, 10)
+			}
+
+			if got := c.GetInt("subkey_int_value", 0); got != -100 {
+				t.Errorf("configureImpl.GetInt() = %v, want %v", got, -100)
+			}
+
+			out := struct {
+				MaxIdle int `yaml:"max_idle"`
+			}{}
+			if err := c.UnmarshalKey("client.redis", &out); err != nil || out.MaxIdle != 10 {
+				t.Errorf("configureImpl.UnmarshalKey() = %+v, error = %v, want max_idle 10", out, err)
+			}
+		})
+	}
+}
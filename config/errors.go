@@ -6,6 +6,9 @@ var (
 	// ErrUnmarshalerNotExist unmarshaler not exist
 	ErrUnmarshalerNotExist = fmt.Errorf("%s: unmarshaler not exist", packageName)
 
+	// ErrMarshalerNotExist unmarshaler can not marshal data
+	ErrMarshalerNotExist = fmt.Errorf("%s: marshaler not exist", packageName)
+
 	// ErrConfigNotExist config not exist
 	ErrConfigNotExist = fmt.Errorf("%s: config not exist", packageName)
 )
@@ -1,13 +1,17 @@
 module github.com/wwwangxc/go-pkg/config
 
-go 1.17
+go 1.18
 
 require (
 	github.com/BurntSushi/toml v1.0.0
 	github.com/agiledragon/gomonkey v2.0.2+incompatible
 	github.com/fsnotify/fsnotify v1.5.1
 	github.com/golang/mock v1.6.0
+	github.com/hashicorp/hcl v1.0.0
+	github.com/magiconair/properties v1.8.6
+	github.com/santhosh-tekuri/jsonschema/v5 v5.0.0
 	github.com/spf13/cast v1.4.1
+	gopkg.in/ini.v1 v1.66.4
 	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
 )
 
@@ -0,0 +1,235 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cast"
+
+	"github.com/wwwangxc/go-pkg/config/unmarshaler"
+)
+
+// refPattern reference to other key, e.g. ${client.redis.dsn} or ${client.redis.dsn:-default}
+var refPattern = regexp.MustCompile(`\$\{([^{}:\s"]+\.[^{}:\s"]+)(:-([^{}\n]*))?\}`)
+
+// refResolver resolve references to other keys in string values
+//
+// the value is kept as it is when the string is exactly one reference without default,
+// e.g. timeout: ${client.timeout} keeps the type of client.timeout.
+type refResolver struct {
+	data map[string]interface{}
+
+	// secrets dotted keys of the secret values => original values, e.g. ENC(...),
+	// the value referencing secret is secret too, whose original value is the unresolved string.
+	secrets map[string]interface{}
+
+	// originals dotted keys of the resolved values => the unresolved strings,
+	// the original values interpolated from environment variables or files are kept.
+	originals map[string]interface{}
+
+	// resolving keys being resolved, used to detect reference cycle
+	resolving map[string]bool
+	changed   bool
+}
+
+func (r *refResolver) resolveMap(m map[string]interface{}, prefix string) error {
+	for k, v := range m {
+		val, err := r.resolveValue(v, joinKey(prefix, k))
+		if err != nil {
+			return err
+		}
+
+		m[k] = val
+	}
+
+	return nil
+}
+
+func (r *refResolver) resolveValue(v interface{}, key string) (interface{}, error) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		return val, r.resolveMap(val, key)
+	case map[interface{}]interface{}:
+		m := cast.ToStringMap(val)
+		return m, r.resolveMap(m, key)
+	case []interface{}:
+		for i, item := range val {
+			resolved, err := r.resolveValue(item, joinKey(key, strconv.Itoa(i)))
+			if err != nil {
+				return nil, err
+			}
+			val[i] = resolved
+		}
+		return val, nil
+	case string:
+		return r.resolveString(val, key)
+	default:
+		return v, nil
+	}
+}
+
+func (r *refResolver) resolveString(s, key string) (interface{}, error) {
+	matches := refPattern.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return s, nil
+	}
+
+	if r.resolving[key] {
+		return nil, fmt.Errorf("reference cycle. key:%s", key)
+	}
+
+	r.resolving[key] = true
+	defer delete(r.resolving, key)
+
+	r.changed = true
+	if _, exist := r.originals[key]; !exist && r.originals != nil {
+		r.originals[key] = s
+	}
+
+	var buf strings.Builder
+	last := 0
+	for _, match := range matches {
+		ref := s[match[2]:match[3]]
+		refKey, val, exist, err := r.lookup(ref)
+		if err != nil {
+			return nil, err
+		}
+
+		if exist && r.isSecret(refKey) {
+			r.secrets[key] = s
+		}
+
+		hasDefault := match[4] >= 0
+		if !exist && !hasDefault {
+			return nil, fmt.Errorf("reference not exist. key:%s reference:%s", key, ref)
+		}
+
+		if !exist {
+			val = s[match[6]:match[7]]
+		}
+
+		// keep the type when the string is exactly one reference
+		if len(matches) == 1 && match[0] == 0 && match[1] == len(s) && exist {
+			return val, nil
+		}
+
+		buf.WriteString(s[last:match[0]])
+		buf.WriteString(cast.ToString(val))
+		last = match[1]
+	}
+	buf.WriteString(s[last:])
+
+	return buf.String(), nil
+}
+
+// lookup return the dotted key and the resolved value of the referenced key path
+//
+// the dotted key is the reference itself when reference contains wildcard.
+func (r *refResolver) lookup(ref string) (string, interface{}, bool, error) {
+	key, val, exist := fetchByKey(r.data, ref, false)
+	if !exist {
+		return "", nil, false, nil
+	}
+
+	if key == "" {
+		key = ref
+	}
+
+	val, err := r.resolveValue(val, key)
+	if err != nil {
+		return "", nil, false, err
+	}
+
+	return key, val, true, nil
+}
+
+// isSecret report whether the value of key is secret or contains secret
+func (r *refResolver) isSecret(key string) bool {
+	for secret := range r.secrets {
+		if hasKeyPrefix(secret, key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// resolveRefs resolve references to other keys in data in place
+//
+// secrets is updated with the keys referencing secret, originals is updated with the unresolved strings,
+// return true when any reference resolved.
+func resolveRefs(data, secrets, originals map[string]interface{}) (bool, error) {
+	r := &refResolver{
+		data:      data,
+		secrets:   secrets,
+		originals: originals,
+		resolving: map[string]bool{},
+	}
+
+	if err := r.resolveMap(data, ""); err != nil {
+		return false, err
+	}
+
+	return r.changed, nil
+}
+
+// notRestorable original value of the interpolated value is unknown,
+// since the file can not be decoded without interpolation, e.g. port = ${PORT} of toml.
+type notRestorable struct{}
+
+// traceInterpolated record the original values of the values interpolated from environment variables or files
+//
+// the file is decoded without interpolation, and the leaf values differ from the interpolated ones are recorded.
+func (f *fileSource) traceInterpolated(u unmarshaler.Unmarshaler, raw []byte, data map[string]interface{}) {
+	overwriteInterpolated(f.interpolated, data, nil)
+	if !bytes.Contains(raw, []byte("${")) {
+		return
+	}
+
+	leaves := map[string]interface{}{}
+	flattenMap(data, "", leaves)
+
+	originals := map[string]interface{}{}
+	m, ok := u.(unmarshaler.Marshaler)
+	if !ok || m.Decode(raw, &originals) != nil {
+		for k := range leaves {
+			f.interpolated[k] = notRestorable{}
+		}
+		return
+	}
+
+	originalLeaves := map[string]interface{}{}
+	flattenMap(originals, "", originalLeaves)
+	for k, val := range leaves {
+		original, exist := originalLeaves[k]
+		switch {
+		case !exist:
+			f.interpolated[k] = notRestorable{}
+		case !reflect.DeepEqual(original, val):
+			f.interpolated[k] = original
+		}
+	}
+}
+
+// overwriteInterpolated remove the original values of the leaf values of data, which overwrite the lower layers,
+// and add the original values of the layer.
+func overwriteInterpolated(interpolated, data, originals map[string]interface{}) {
+	leaves := map[string]interface{}{}
+	flattenMap(data, "", leaves)
+	for k := range interpolated {
+		for leaf := range leaves {
+			if hasKeyPrefix(k, leaf) || hasKeyPrefix(leaf, k) {
+				delete(interpolated, k)
+				break
+			}
+		}
+	}
+
+	for k, original := range originals {
+		interpolated[k] = original
+	}
+}
@@ -0,0 +1,173 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_resolveRefs(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        map[string]interface{}
+		want        map[string]interface{}
+		wantChanged bool
+		wantErr     bool
+	}{
+		{
+			name:        "no reference",
+			data:        map[string]interface{}{"a": "${A}", "b": 1},
+			want:        map[string]interface{}{"a": "${A}", "b": 1},
+			wantChanged: false,
+		},
+		{
+			name: "reference",
+			data: map[string]interface{}{
+				"client": map[string]interface{}{
+					"host":    "127.0.0.1",
+					"port":    6379,
+					"timeout": 1000,
+				},
+				"dsn":     "redis://${client.host}:${client.port}",
+				"timeout": "${client.timeout}",
+				"slice":   []interface{}{"${client.host}"},
+			},
+			want: map[string]interface{}{
+				"client": map[string]interface{}{
+					"host":    "127.0.0.1",
+					"port":    6379,
+					"timeout": 1000,
+				},
+				"dsn":     "redis://127.0.0.1:6379",
+				"timeout": 1000,
+				"slice":   []interface{}{"127.0.0.1"},
+			},
+			wantChanged: true,
+		},
+		{
+			name: "reference slice element",
+			data: map[string]interface{}{
+				"service": []interface{}{
+					map[string]interface{}{"name": "redis", "host": "127.0.0.1"},
+					map[string]interface{}{"name": "cache", "host": "127.0.0.2"},
+				},
+				"a": "${service.0.host}",
+				"b": "${service[name=cache].host}",
+			},
+			want: map[string]interface{}{
+				"service": []interface{}{
+					map[string]interface{}{"name": "redis", "host": "127.0.0.1"},
+					map[string]interface{}{"name": "cache", "host": "127.0.0.2"},
+				},
+				"a": "127.0.0.1",
+				"b": "127.0.0.2",
+			},
+			wantChanged: true,
+		},
+		{
+			name:        "default",
+			data:        map[string]interface{}{"a": "${not.exist:-default value}"},
+			want:        map[string]interface{}{"a": "default value"},
+			wantChanged: true,
+		},
+		{
+			name:    "reference not exist",
+			data:    map[string]interface{}{"a": "${not.exist}"},
+			wantErr: true,
+		},
+		{
+			name: "reference cycle",
+			data: map[string]interface{}{
+				"a": map[string]interface{}{"b": "${c.d}"},
+				"c": map[string]interface{}{"d": "${a.b}"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed, err := resolveRefs(tt.data, map[string]interface{}{}, map[string]interface{}{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("resolveRefs() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if changed != tt.wantChanged {
+				t.Errorf("resolveRefs() changed = %v, want %v", changed, tt.wantChanged)
+			}
+
+			if !reflect.DeepEqual(tt.data, tt.want) {
+				t.Errorf("resolveRefs() data = %v, want %v", tt.data, tt.want)
+			}
+		})
+	}
+}
+
+func Test_configureImpl_interpolate(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "db_password"), "secret\n")
+
+	tests := []struct {
+		name    string
+		data    string
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name: "env default",
+			data: "a: ${CONFIG_TEST_NOT_EXIST:-default value}\n",
+			want: map[string]interface{}{"a": "default value"},
+		},
+		{
+			name:    "env required",
+			data:    "a: ${CONFIG_TEST_NOT_EXIST:?dsn is required}\n",
+			wantErr: true,
+		},
+		{
+			name: "file",
+			data: "password: ${file:" + filepath.Join(dir, "db_password") + "}\n",
+			want: map[string]interface{}{"password": "secret"},
+		},
+		{
+			name:    "file not exist",
+			data:    "password: ${file:" + filepath.Join(dir, "not_exist") + "}\n",
+			wantErr: true,
+		},
+		{
+			name: "reference",
+			data: "db:\n  password: secret\ndsn: root:${db.password}@tcp(127.0.0.1:3306)/db\n",
+			want: map[string]interface{}{
+				"db":  map[string]interface{}{"password": "secret"},
+				"dsn": "root:secret@tcp(127.0.0.1:3306)/db",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "app.yaml")
+			writeFile(t, path, tt.data)
+
+			c := defaultConfigure(path)
+			defer c.Close()
+			withTest()(c)
+			err := c.Load()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("configureImpl.Load() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			got := map[string]interface{}{}
+			if err = c.Unmarshal(&got); err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("configureImpl.Unmarshal() = %v, error = %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
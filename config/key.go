@@ -0,0 +1,502 @@
+package config
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// fetch fetch value of key path from m, see parseKey for the syntax of key path
+//
+// keys are matched ignoring case, underscores and hyphens when WithCaseInsensitive,
+// the key under the old key of alias is fetched by the new key.
+func (c *configureImpl) fetch(m map[string]interface{}, k string) (interface{}, bool) {
+	_, val, exist := c.lookupKey(m, k)
+	return val, exist
+}
+
+// resolveKey resolve key path into the dotted key exist in m, see fetch.
+//
+// key path with wildcard can not be resolved.
+func (c *configureImpl) resolveKey(m map[string]interface{}, k string) (string, bool) {
+	key, _, exist := c.lookupKey(m, k)
+	return key, exist && key != ""
+}
+
+func (c *configureImpl) lookupKey(m map[string]interface{}, k string) (string, interface{}, bool) {
+	key, val, exist := c.matchKey(m, k)
+	if exist {
+		return key, val, true
+	}
+
+	for old, newKey := range c.aliases {
+		if hasKeyPrefix(k, old) {
+			return c.matchKey(m, newKey+k[len(old):])
+		}
+	}
+
+	return "", nil, false
+}
+
+// matchKey match the key path in m, return the matched dotted key
+//
+// the flat keys of environment variables separated by "_" are matched when the key path not exist,
+// see fetchJoined.
+func (c *configureImpl) matchKey(m map[string]interface{}, k string) (string, interface{}, bool) {
+	key, val, exist := fetchByKey(m, k, c.caseInsensitive)
+	if exist || !c.hasFlatEnvKeys() {
+		return key, val, exist
+	}
+
+	return fetchJoined(m, k, c.caseInsensitive)
+}
+
+// hasFlatEnvKeys report whether any environment variable source may produce flat keys,
+// whose levels are separated by "_" and not matched in the lower precedence sources.
+func (c *configureImpl) hasFlatEnvKeys() bool {
+	for _, e := range c.envs {
+		if e.keyMapper == nil && e.separator == "_" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// keyToken one level of key path
+type keyToken struct {
+	// name map key or slice index
+	name string
+
+	// field, value selector [field=value] match the element whose field equals to value
+	field, value string
+	selector     bool
+
+	// wildcard * or [*] match all elements
+	wildcard bool
+}
+
+// parseKey parse key path into tokens
+//
+// key path support:
+//
+//	client.service.0.dsn                 slice index
+//	client.service[0].dsn                slice index
+//	client.service[name=cache].dsn       the first element whose name is cache
+//	client.service.*.dsn                 dsn of all elements, same as client.service[*].dsn
+func parseKey(k string) []keyToken {
+	var tokens []keyToken
+	var name strings.Builder
+	flush := func() {
+		if name.Len() == 0 {
+			return
+		}
+
+		if name.String() == "*" {
+			tokens = append(tokens, keyToken{wildcard: true})
+		} else {
+			tokens = append(tokens, keyToken{name: name.String()})
+		}
+		name.Reset()
+	}
+
+	for i := 0; i < len(k); i++ {
+		switch k[i] {
+		case '.':
+			flush()
+		case '[':
+			end := strings.IndexByte(k[i:], ']')
+			if end < 0 {
+				name.WriteString(k[i:])
+				i = len(k)
+				continue
+			}
+
+			flush()
+			tokens = append(tokens, parseBracket(k[i+1:i+end]))
+			i += end
+		default:
+			name.WriteByte(k[i])
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+func parseBracket(s string) keyToken {
+	s = strings.TrimSpace(s)
+	if s == "*" {
+		return keyToken{wildcard: true}
+	}
+
+	kv := strings.SplitN(s, "=", 2)
+	if len(kv) != 2 {
+		return keyToken{name: s}
+	}
+
+	return keyToken{
+		field:    strings.TrimSpace(kv[0]),
+		value:    strings.Trim(strings.TrimSpace(kv[1]), `"'`),
+		selector: true,
+	}
+}
+
+// fetchByKey fetch value of key path from v, return the matched dotted key
+//
+// slice elements are addressed by index, e.g. client.service.0.dsn, see parseKey.
+// the value of wildcard is the slice of matched values, and the dotted key is empty,
+// the key not exist when no value matched.
+// keys are matched ignoring case, underscores and hyphens when fold.
+func fetchByKey(v interface{}, k string, fold bool) (string, interface{}, bool) {
+	tokens := parseKey(k)
+	if len(tokens) == 0 {
+		return "", nil, false
+	}
+
+	return walkTokens(v, tokens, nil, fold)
+}
+
+func walkTokens(v interface{}, tokens []keyToken, path []string, fold bool) (string, interface{}, bool) {
+	if len(tokens) == 0 {
+		return strings.Join(path, "."), v, true
+	}
+
+	token, rest := tokens[0], tokens[1:]
+	if token.wildcard || token.selector {
+		return walkChildren(v, token, rest, path, fold)
+	}
+
+	if m, ok := toStringMap(v); ok {
+		key := token.name
+		if _, exist := m[key]; !exist && fold {
+			key, _ = matchKeyFold(m, key)
+		}
+
+		val, exist := m[key]
+		if !exist {
+			return "", nil, false
+		}
+		return walkTokens(val, rest, appendKey(path, key), fold)
+	}
+
+	items, ok := v.([]interface{})
+	if !ok {
+		return "", nil, false
+	}
+
+	i, err := strconv.Atoi(token.name)
+	if err != nil || i < 0 || i >= len(items) {
+		return "", nil, false
+	}
+
+	return walkTokens(items[i], rest, appendKey(path, token.name), fold)
+}
+
+// fetchJoined fetch value of key path from v like fetchByKey, and the subkeys are joined by "_" to match
+// the flat keys, e.g. client.redis.max_idle matches client_redis_max_idle.
+//
+// the keys under the joined key path are collected into a map, whose dotted key is empty,
+// e.g. client.redis matches {max_idle: 10} of client_redis_max_idle.
+func fetchJoined(v interface{}, k string, fold bool) (string, interface{}, bool) {
+	tokens := parseKey(k)
+	if len(tokens) == 0 {
+		return "", nil, false
+	}
+
+	return walkJoined(v, tokens, nil, fold)
+}
+
+func walkJoined(v interface{}, tokens []keyToken, path []string, fold bool) (string, interface{}, bool) {
+	if len(tokens) == 0 {
+		return strings.Join(path, "."), v, true
+	}
+
+	n := 0
+	for n < len(tokens) && !tokens[n].wildcard && !tokens[n].selector {
+		n++
+	}
+
+	m, ok := toStringMap(v)
+	if !ok || n == 0 {
+		return walkTokens(v, tokens, path, fold)
+	}
+
+	for i := n; i > 0; i-- {
+		name := joinTokens(tokens[:i], "_")
+		key := name
+		if _, exist := m[key]; !exist && fold {
+			key, _ = matchKeyFold(m, name)
+		}
+
+		val, exist := m[key]
+		if !exist {
+			continue
+		}
+
+		if k, val, exist := walkJoined(val, tokens[i:], appendKey(path, key), fold); exist {
+			return k, val, true
+		}
+	}
+
+	if n < len(tokens) {
+		return "", nil, false
+	}
+
+	prefix := joinTokens(tokens, "_") + "_"
+	sub := map[string]interface{}{}
+	for key, val := range m {
+		if len(key) > len(prefix) && (strings.HasPrefix(key, prefix) ||
+			(fold && strings.EqualFold(key[:len(prefix)], prefix))) {
+			sub[key[len(prefix):]] = val
+		}
+	}
+
+	if len(sub) == 0 {
+		return "", nil, false
+	}
+
+	return "", sub, true
+}
+
+func joinTokens(tokens []keyToken, sep string) string {
+	names := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		names = append(names, token.name)
+	}
+
+	return strings.Join(names, sep)
+}
+
+// walkChildren walk the children of v matched by wildcard or selector
+func walkChildren(v interface{}, token keyToken, rest []keyToken, path []string, fold bool) (string,
+	interface{}, bool) {
+	keys, children, ok := childrenOf(v)
+	if !ok {
+		return "", nil, false
+	}
+
+	if token.wildcard {
+		vals := []interface{}{}
+		for _, child := range children {
+			if _, val, exist := walkTokens(child, rest, nil, fold); exist {
+				vals = append(vals, val)
+			}
+		}
+		if len(vals) == 0 {
+			return "", nil, false
+		}
+		return "", vals, true
+	}
+
+	for i, child := range children {
+		m, ok := toStringMap(child)
+		if !ok {
+			continue
+		}
+
+		field, exist := m[token.field]
+		if !exist && fold {
+			var key string
+			key, exist = matchKeyFold(m, token.field)
+			field = m[key]
+		}
+
+		if exist && cast.ToString(field) == token.value {
+			return walkTokens(child, rest, appendKey(path, keys[i]), fold)
+		}
+	}
+
+	return "", nil, false
+}
+
+// childrenOf keys and values of map sorted by key, or indexes and elements of slice
+func childrenOf(v interface{}) ([]string, []interface{}, bool) {
+	if m, ok := toStringMap(v); ok {
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		children := make([]interface{}, 0, len(keys))
+		for _, k := range keys {
+			children = append(children, m[k])
+		}
+		return keys, children, true
+	}
+
+	if items, ok := v.([]interface{}); ok {
+		keys := make([]string, 0, len(items))
+		for i := range items {
+			keys = append(keys, strconv.Itoa(i))
+		}
+		return keys, items, true
+	}
+
+	return nil, nil, false
+}
+
+func appendKey(path []string, k string) []string {
+	return append(path[:len(path):len(path)], k)
+}
+
+// Keys list dotted keys of leaf values under prefix in order
+//
+// slices are expanded by index, e.g. client.service.0.name.
+// list all keys when prefix is empty, return nil when prefix not exist.
+// prefix support key path like getters, e.g. client.service[name=cache]
+func (c *configureImpl) Keys(prefix string) []string {
+	return c.keys("", prefix)
+}
+
+// keys list keys of leaf values under prefix relative to base, base is the root when empty
+func (c *configureImpl) keys(base, prefix string) []string {
+	c.rw.RLock()
+	defer c.rw.RUnlock()
+
+	var val interface{} = c.unmarshaledData
+	if base != "" {
+		var exist bool
+		if val, exist = c.fetch(c.unmarshaledData, base); !exist {
+			return nil
+		}
+	}
+
+	key := ""
+	if prefix != "" {
+		var exist bool
+		if base == "" {
+			key, val, exist = c.lookupKey(c.unmarshaledData, prefix)
+		} else {
+			key, val, exist = fetchByKey(val, prefix, c.caseInsensitive)
+		}
+
+		if !exist || key == "" {
+			return nil
+		}
+	}
+
+	var keys []string
+	collectKeys(val, key, &keys)
+	return keys
+}
+
+func collectKeys(v interface{}, prefix string, keys *[]string) {
+	childKeys, children, ok := childrenOf(v)
+	if !ok || len(children) == 0 {
+		if prefix != "" {
+			*keys = append(*keys, prefix)
+		}
+		return
+	}
+
+	for i, child := range children {
+		collectKeys(child, joinKey(prefix, childKeys[i]), keys)
+	}
+}
+
+// matchKeyFold match k in m ignoring case, underscores and hyphens, the exact key takes precedence
+func matchKeyFold(m map[string]interface{}, k string) (string, bool) {
+	if _, exist := m[k]; exist {
+		return k, true
+	}
+
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	folded := foldKey(k)
+	for _, key := range keys {
+		if foldKey(key) == folded {
+			return key, true
+		}
+	}
+
+	return "", false
+}
+
+func foldKey(k string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(k))
+}
+
+// applyAliases move the values of the old keys to the new keys in place, and log deprecation warning
+//
+// the value of the new key takes precedence, maps are deep merged. the origins are moved too.
+// return true when any old key exist.
+func (c *configureImpl) applyAliases(data map[string]interface{}, origins map[string]*Origin,
+	interpolated map[string]interface{}) bool {
+	olds := make([]string, 0, len(c.aliases))
+	for old := range c.aliases {
+		olds = append(olds, old)
+	}
+	sort.Strings(olds)
+
+	applied := false
+	for _, old := range olds {
+		newKey := c.aliases[old]
+		val, exist := fetchFromMap(data, strings.Split(old, "."))
+		if !exist {
+			continue
+		}
+
+		deleteFromMap(data, strings.Split(old, "."))
+		if cur, exist := fetchFromMap(data, strings.Split(newKey, ".")); exist {
+			oldMap, oldIsMap := toStringMap(val)
+			curMap, curIsMap := toStringMap(cur)
+			if oldIsMap && curIsMap {
+				val = mergeMap(mergeMap(nil, oldMap), curMap)
+			} else {
+				val = cur
+			}
+		}
+		setToMap(data, strings.Split(newKey, "."), val)
+
+		for k, origin := range origins {
+			if !hasKeyPrefix(k, old) {
+				continue
+			}
+
+			delete(origins, k)
+			if _, exist := origins[newKey+k[len(old):]]; !exist {
+				origins[newKey+k[len(old):]] = origin
+			}
+		}
+
+		for k, original := range interpolated {
+			if !hasKeyPrefix(k, old) {
+				continue
+			}
+
+			delete(interpolated, k)
+			if _, exist := interpolated[newKey+k[len(old):]]; !exist {
+				interpolated[newKey+k[len(old):]] = original
+			}
+		}
+
+		applied = true
+		logWarn("%s: key %s is deprecated, use %s instead\n", packageName, old, newKey)
+	}
+
+	return applied
+}
+
+// deleteFromMap delete the value of subkeys from nested map
+func deleteFromMap(m map[string]interface{}, subkeys []string) {
+	if len(subkeys) == 1 {
+		delete(m, subkeys[0])
+		return
+	}
+
+	sub, ok := toStringMap(m[subkeys[0]])
+	if !ok {
+		return
+	}
+
+	deleteFromMap(sub, subkeys[1:])
+	m[subkeys[0]] = sub
+}
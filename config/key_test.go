@@ -0,0 +1,435 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_configureImpl_fetch(t *testing.T) {
+	data := map[string]interface{}{
+		"client": map[string]interface{}{
+			"redis": map[string]interface{}{"max_idle": 10, "MaxIdle": 20},
+			"mysql": map[string]interface{}{"max-open": 5},
+		},
+	}
+
+	tests := []struct {
+		name            string
+		caseInsensitive bool
+		aliases         map[string]string
+		key             string
+		wantKey         string
+		want            interface{}
+		wantExist       bool
+	}{
+		{
+			name:      "exact",
+			key:       "client.redis.max_idle",
+			wantKey:   "client.redis.max_idle",
+			want:      10,
+			wantExist: true,
+		},
+		{
+			name: "case sensitive",
+			key:  "client.Redis.max_idle",
+		},
+		{
+			name:            "case insensitive",
+			caseInsensitive: true,
+			key:             "Client.REDIS.max_idle",
+			wantKey:         "client.redis.max_idle",
+			want:            10,
+			wantExist:       true,
+		},
+		{
+			name:            "exact key takes precedence",
+			caseInsensitive: true,
+			key:             "client.Redis.MaxIdle",
+			wantKey:         "client.redis.MaxIdle",
+			want:            20,
+			wantExist:       true,
+		},
+		{
+			name:            "separators ignored",
+			caseInsensitive: true,
+			key:             "client.mysql.MaxOpen",
+			wantKey:         "client.mysql.max-open",
+			want:            5,
+			wantExist:       true,
+		},
+		{
+			name:      "read by old key",
+			aliases:   map[string]string{"client.redis_pool": "client.redis"},
+			key:       "client.redis_pool.max_idle",
+			wantKey:   "client.redis.max_idle",
+			want:      10,
+			wantExist: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &configureImpl{caseInsensitive: tt.caseInsensitive, aliases: tt.aliases}
+			got, exist := c.fetch(data, tt.key)
+			if exist != tt.wantExist || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("configureImpl.fetch() = %v, %v, want %v, %v", got, exist, tt.want, tt.wantExist)
+			}
+
+			if gotKey, _ := c.resolveKey(data, tt.key); gotKey != tt.wantKey {
+				t.Errorf("configureImpl.resolveKey() = %v, want %v", gotKey, tt.wantKey)
+			}
+		})
+	}
+}
+
+func Test_configureImpl_applyAliases(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    map[string]interface{}
+		wantKey string
+	}{
+		{
+			name:    "old key moved",
+			data:    "client:\n  redis_pool:\n    max_idle: 10\n",
+			want:    map[string]interface{}{"client": map[string]interface{}{"redis": map[string]interface{}{"max_idle": 10}}},
+			wantKey: "client.redis.max_idle",
+		},
+		{
+			name: "new key takes precedence",
+			data: "client:\n  redis_pool:\n    max_idle: 10\n    dsn: old\n  redis:\n    max_idle: 20\n",
+			want: map[string]interface{}{"client": map[string]interface{}{
+				"redis": map[string]interface{}{"max_idle": 20, "dsn": "old"},
+			}},
+			wantKey: "client.redis.dsn",
+		},
+		{
+			name:    "top level key renamed",
+			data:    "debug: true\n",
+			want:    map[string]interface{}{"app": map[string]interface{}{"debug": true}},
+			wantKey: "app.debug",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "app.yaml")
+			writeFile(t, path, tt.data)
+
+			c := defaultConfigure(path)
+			defer c.Close()
+			withTest()(c)
+			WithKeyAliases(map[string]string{"client.redis_pool": "client.redis", "debug": "app.debug"})(c)
+			if err := c.Load(); err != nil {
+				t.Fatalf("configureImpl.Load() error = %v", err)
+			}
+
+			got := map[string]interface{}{}
+			if err := c.Unmarshal(&got); err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("configureImpl.Unmarshal() = %v, error = %v, want %v", got, err, tt.want)
+			}
+
+			if origin := c.origins[tt.wantKey]; origin == nil || origin.Name != path {
+				t.Errorf("origin of %s = %+v, want file %s", tt.wantKey, origin, path)
+			}
+		})
+	}
+}
+
+func Test_parseKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want []keyToken
+	}{
+		{key: "a.b", want: []keyToken{{name: "a"}, {name: "b"}}},
+		{key: "a.0.b", want: []keyToken{{name: "a"}, {name: "0"}, {name: "b"}}},
+		{key: "a[0].b", want: []keyToken{{name: "a"}, {name: "0"}, {name: "b"}}},
+		{key: "a[name = 'x'].b", want: []keyToken{{name: "a"}, {field: "name", value: "x", selector: true}, {name: "b"}}},
+		{key: "a.*.b", want: []keyToken{{name: "a"}, {wildcard: true}, {name: "b"}}},
+		{key: "a[*]", want: []keyToken{{name: "a"}, {wildcard: true}}},
+		{key: "a[0", want: []keyToken{{name: "a[0"}}},
+		{key: "", want: nil},
+	}
+	for _, tt := range tests {
+		if got := parseKey(tt.key); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseKey(%q) = %+v, want %+v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func Test_fetchByKey(t *testing.T) {
+	data := map[string]interface{}{
+		"client": map[string]interface{}{
+			"service": []interface{}{
+				map[string]interface{}{"name": "redis", "dsn": "redis://a"},
+				map[string]interface{}{"name": "cache", "dsn": "redis://b", "port": 6379},
+			},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		key       string
+		fold      bool
+		wantKey   string
+		want      interface{}
+		wantExist bool
+	}{
+		{
+			name:      "index",
+			key:       "client.service.1.dsn",
+			wantKey:   "client.service.1.dsn",
+			want:      "redis://b",
+			wantExist: true,
+		},
+		{
+			name:      "bracket index",
+			key:       "client.service[0].name",
+			wantKey:   "client.service.0.name",
+			want:      "redis",
+			wantExist: true,
+		},
+		{
+			name:      "selector",
+			key:       "client.service[name=cache].dsn",
+			wantKey:   "client.service.1.dsn",
+			want:      "redis://b",
+			wantExist: true,
+		},
+		{
+			name:      "selector by number",
+			key:       "client.service[port=6379].name",
+			wantKey:   "client.service.1.name",
+			want:      "cache",
+			wantExist: true,
+		},
+		{
+			name:      "selector ignoring case",
+			key:       "client.Service[Name=cache].DSN",
+			fold:      true,
+			wantKey:   "client.service.1.dsn",
+			want:      "redis://b",
+			wantExist: true,
+		},
+		{
+			name:      "wildcard",
+			key:       "client.service.*.name",
+			want:      []interface{}{"redis", "cache"},
+			wantExist: true,
+		},
+		{
+			name:      "wildcard skip missing",
+			key:       "client.service[*].port",
+			want:      []interface{}{6379},
+			wantExist: true,
+		},
+		{
+			name: "wildcard not matched",
+			key:  "client.service.*.nope",
+		},
+		{
+			name: "wildcard of map not matched",
+			key:  "client.*.foo",
+		},
+		{
+			name: "selector not matched",
+			key:  "client.service[name=mysql].dsn",
+		},
+		{
+			name: "index out of range",
+			key:  "client.service.2.dsn",
+		},
+		{
+			name: "index of map",
+			key:  "client.0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotKey, got, exist := fetchByKey(data, tt.key, tt.fold)
+			if gotKey != tt.wantKey || exist != tt.wantExist || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fetchByKey() = %v, %v, %v, want %v, %v, %v",
+					gotKey, got, exist, tt.wantKey, tt.want, tt.wantExist)
+			}
+		})
+	}
+}
+
+func Test_fetchJoined(t *testing.T) {
+	data := map[string]interface{}{
+		"client_redis_max_idle": 10,
+		"client_redis_dsn":      "redis://a",
+		"mysql":                 map[string]interface{}{"max_open": 5},
+	}
+
+	tests := []struct {
+		name      string
+		key       string
+		fold      bool
+		wantKey   string
+		want      interface{}
+		wantExist bool
+	}{
+		{
+			name:      "flat key",
+			key:       "client.redis.max_idle",
+			wantKey:   "client_redis_max_idle",
+			want:      10,
+			wantExist: true,
+		},
+		{
+			name:      "flat key ignoring case",
+			key:       "Client.Redis.MAX_IDLE",
+			fold:      true,
+			wantKey:   "client_redis_max_idle",
+			want:      10,
+			wantExist: true,
+		},
+		{
+			name:      "nested key",
+			key:       "mysql.max.open",
+			wantKey:   "mysql.max_open",
+			want:      5,
+			wantExist: true,
+		},
+		{
+			name:      "keys under prefix",
+			key:       "client.redis",
+			want:      map[string]interface{}{"max_idle": 10, "dsn": "redis://a"},
+			wantExist: true,
+		},
+		{
+			name: "not exist",
+			key:  "client.mysql",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotKey, got, exist := fetchJoined(data, tt.key, tt.fold)
+			if gotKey != tt.wantKey || exist != tt.wantExist || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fetchJoined() = %v, %v, %v, want %v, %v, %v",
+					gotKey, got, exist, tt.wantKey, tt.want, tt.wantExist)
+			}
+		})
+	}
+}
+
+func Test_configureImpl_Keys(t *testing.T) {
+	files := []struct {
+		name string
+		data string
+	}{
+		{
+			name: "app.yaml",
+			data: "client:\n  timeout: 1s\n  service:\n    - name: redis\n      dsn: redis://a\n" +
+				"    - name: cache\n      dsn: redis://b\n  tags: []\n",
+		},
+		{
+			// arrays of tables
+			name: "app.toml",
+			data: "[client]\ntimeout = '1s'\ntags = []\n\n[[client.service]]\nname = 'redis'\ndsn = 'redis://a'\n\n" +
+				"[[client.service]]\nname = 'cache'\ndsn = 'redis://b'\n",
+		},
+	}
+	for _, file := range files {
+		t.Run(file.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), file.name)
+			writeFile(t, path, file.data)
+			testKeys(t, path)
+		})
+	}
+}
+
+func testKeys(t *testing.T, path string) {
+	c := defaultConfigure(path)
+	defer c.Close()
+	withTest()(c)
+	if err := c.Load(); err != nil {
+		t.Fatalf("configureImpl.Load() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		configure Configure
+		prefix    string
+		want      []string
+	}{
+		{
+			name:      "all keys",
+			configure: c,
+			want: []string{"client.service.0.dsn", "client.service.0.name", "client.service.1.dsn",
+				"client.service.1.name", "client.tags", "client.timeout"},
+		},
+		{
+			name:      "selector",
+			configure: c,
+			prefix:    "client.service[name=cache]",
+			want:      []string{"client.service.1.dsn", "client.service.1.name"},
+		},
+		{
+			name:      "leaf",
+			configure: c,
+			prefix:    "client.timeout",
+			want:      []string{"client.timeout"},
+		},
+		{
+			name:      "not exist",
+			configure: c,
+			prefix:    "client.mysql",
+		},
+		{
+			name:      "sub",
+			configure: c.Sub("client.service"),
+			prefix:    "1",
+			want:      []string{"1.dsn", "1.name"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.configure.Keys(tt.prefix); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Keys() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := c.GetString("client.service[name=cache].dsn", ""); got != "redis://b" {
+		t.Errorf("configureImpl.GetString() = %v, want redis://b", got)
+	}
+
+	if got := c.GetStringSlice("client.service.*.name", nil); !reflect.DeepEqual(got, []string{"redis", "cache"}) {
+		t.Errorf("configureImpl.GetStringSlice() = %v, want [redis cache]", got)
+	}
+
+	if !c.IsExist("client.service.1") || c.IsExist("client.service.2") {
+		t.Errorf("configureImpl.IsExist() of slice index is wrong")
+	}
+
+	if got := c.GetString("client.service.0.name", ""); got != "redis" {
+		t.Errorf("configureImpl.GetString() = %v, want redis", got)
+	}
+
+	if c.IsExist("client.service.*.nope") || c.IsExist("client.*.foo") {
+		t.Errorf("configureImpl.IsExist() of wildcard not matched is wrong")
+	}
+
+	if got := c.Get("client.service.*.nope", "DEF"); got != "DEF" {
+		t.Errorf("configureImpl.Get() = %v, want DEF", got)
+	}
+
+	if !c.IsExist("client.service[1]") {
+		t.Errorf("configureImpl.IsExist() of slice index in brackets is wrong")
+	}
+
+	type service struct {
+		Name string `yaml:"name" toml:"name"`
+		DSN  string `yaml:"dsn" toml:"dsn"`
+	}
+
+	var out service
+	if err := c.UnmarshalKey("client.service.1", &out); err != nil || out.DSN != "redis://b" {
+		t.Errorf("configureImpl.UnmarshalKey() = %v, error = %v, want dsn redis://b", out, err)
+	}
+
+	if err := c.Set("client.service.0.dsn", "redis://c"); err == nil {
+		t.Errorf("configureImpl.Set() error = nil, want element of slice error")
+	}
+}
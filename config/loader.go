@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"path/filepath"
 	"sync"
 )
 
@@ -27,19 +28,26 @@ func (l *loader) Load(path string, opts ...LoadOption) (Configure, error) {
 		opt(c)
 	}
 
+	if c.unmarshaler == nil && detectUnmarshaler(path) == nil {
+		return nil, fmt.Errorf("%w. unknown file extension:%s, assign unmarshaler by WithUnmarshaler",
+			ErrUnmarshalerNotExist, filepath.Ext(path))
+	}
+
 	if c.unmarshaler == nil {
 		return nil, ErrUnmarshalerNotExist
 	}
 
-	key := fmt.Sprintf("%s:%s", path, c.unmarshaler.Name())
+	key := c.key()
 	l.rw.RLock()
 	tmp, exist := l.m[key]
 	l.rw.RUnlock()
 	if exist {
+		_ = c.Close()
 		return tmp, nil
 	}
 
 	if err := c.Load(); err != nil {
+		_ = c.Close()
 		return nil, fmt.Errorf("%s: config load fail. err:%w", packageName, err)
 	}
 
@@ -47,11 +55,57 @@ func (l *loader) Load(path string, opts ...LoadOption) (Configure, error) {
 	l.m[key] = c
 	l.rw.Unlock()
 
-	c.watch(func(c *configureImpl) {
-		l.rw.Lock()
-		defer l.rw.Unlock()
-		l.m[key] = c
-	})
+	c.watch(nil)
 
 	return c, nil
 }
+
+// evict evict the config from cache without closing it
+func (l *loader) evict(c Configure) {
+	l.rw.Lock()
+	defer l.rw.Unlock()
+
+	for key, v := range l.m {
+		if v == c {
+			delete(l.m, key)
+		}
+	}
+}
+
+// Unload close and evict the cached configs loaded from path
+func (l *loader) Unload(path string) {
+	l.rw.Lock()
+	var configs []Configure
+	for key, c := range l.m {
+		if impl, ok := c.(*configureImpl); !ok || impl.path != path {
+			continue
+		}
+
+		configs = append(configs, c)
+		delete(l.m, key)
+	}
+	l.rw.Unlock()
+
+	closeAll(configs)
+}
+
+// Reset close and evict all cached configs
+func (l *loader) Reset() {
+	l.rw.Lock()
+	configs := make([]Configure, 0, len(l.m))
+	for _, c := range l.m {
+		configs = append(configs, c)
+	}
+	l.m = map[string]Configure{}
+	l.rw.Unlock()
+
+	closeAll(configs)
+}
+
+func closeAll(configs []Configure) {
+	for _, c := range configs {
+		if err := c.Close(); err != nil {
+			logErrorf("%s: config close fail. err:%v\n", packageName, err)
+		}
+	}
+}
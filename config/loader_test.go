@@ -1,15 +1,19 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/agiledragon/gomonkey"
 )
 
 func Test_loader_Load(t *testing.T) {
 	defaultConfig := defaultConfigure("path")
+	defer defaultConfig.Close()
 	defaultConfig.watcher = nil
 
 	type args struct {
@@ -36,6 +40,18 @@ func Test_loader_Load(t *testing.T) {
 			want:    nil,
 			wantErr: true,
 		},
+		{
+			name: "unknown file extension",
+			l:    newLoader(),
+			args: args{
+				path: "path.conf",
+				opts: []LoadOption{
+					withTest(),
+				},
+			},
+			want:    nil,
+			wantErr: true,
+		},
 		{
 			name: "hit cache",
 			l: &loader{
@@ -96,7 +112,7 @@ func Test_loader_Load(t *testing.T) {
 				t.Errorf("loader.Load() = %v, want %v", got, tt.want)
 			}
 			if !tt.wantErr {
-				key := fmt.Sprintf("%s:%s", tt.args.path, defaultConfigure(tt.args.path).unmarshaler.Name())
+				key := fmt.Sprintf("%s:%s", tt.args.path, detectUnmarshaler(tt.args.path).Name())
 				if !reflect.DeepEqual(tt.l.m[key], got) {
 					t.Errorf("loader.m = %v, want %v", got, tt.want)
 				}
@@ -104,3 +120,103 @@ func Test_loader_Load(t *testing.T) {
 		})
 	}
 }
+
+func Test_loader_Unload(t *testing.T) {
+	l := newLoader()
+	c1, err := l.Load("./testdata/config.yaml", withTest())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c2, err := l.Load("./testdata/overlay.yaml", withTest())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l.Unload("./testdata/config.yaml")
+	if got := len(l.m); got != 1 {
+		t.Errorf("len(loader.m) = %v, want %v", got, 1)
+	}
+
+	got, err := l.Load("./testdata/config.yaml", withTest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == c1 {
+		t.Errorf("loader.Load() hit cache after unload")
+	}
+
+	l.Reset()
+	if got := len(l.m); got != 0 {
+		t.Errorf("len(loader.m) = %v, want %v", got, 0)
+	}
+
+	got, err = l.Load("./testdata/overlay.yaml", withTest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == c2 {
+		t.Errorf("loader.Load() hit cache after reset")
+	}
+}
+
+func Test_loader_Load_validation(t *testing.T) {
+	l := newLoader()
+	defer l.Reset()
+
+	c, err := l.Load("./testdata/config.yaml", withTest())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errInvalid := errors.New("invalid")
+	validator := func(Configure) error { return errInvalid }
+	if _, err = l.Load("./testdata/config.yaml", withTest(), WithReloadValidator(validator)); !errors.Is(err, errInvalid) {
+		t.Errorf("loader.Load() error = %v, want %v", err, errInvalid)
+	}
+
+	if _, err = l.Load("./testdata/config.yaml", withTest(), WithJSONSchema(`{"required": ["not_exist"]}`)); err == nil {
+		t.Errorf("loader.Load() error = nil, want schema violation")
+	}
+
+	got, err := l.Load("./testdata/config.yaml", withTest())
+	if err != nil || got != c {
+		t.Errorf("loader.Load() = %v, error = %v, want the cached config", got, err)
+	}
+}
+
+func Test_configureImpl_Close(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, path, "value: 1\n")
+
+	c, err := Load(path, WithPollInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = c.Close(); err != nil {
+		t.Errorf("configureImpl.Close() error = %v", err)
+	}
+	if err = c.Close(); err != nil {
+		t.Errorf("configureImpl.Close() error = %v", err)
+	}
+
+	writeFile(t, path, "value: 2\n")
+	time.Sleep(50 * time.Millisecond)
+	if got := c.GetInt("value", 0); got != 1 {
+		t.Errorf("configureImpl.GetInt() = %v, want %v", got, 1)
+	}
+
+	got, err := Load(path, WithPollInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer got.Close()
+
+	if got == c {
+		t.Errorf("Load() hit cache after close")
+	}
+	if got := got.GetInt("value", 0); got != 2 {
+		t.Errorf("configureImpl.GetInt() = %v, want %v", got, 2)
+	}
+}
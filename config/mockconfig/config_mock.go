@@ -6,8 +6,10 @@ package mockconfig
 
 import (
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
+	config "github.com/wwwangxc/go-pkg/config"
 )
 
 // MockConfigure is a mock of Configure interface.
@@ -33,6 +35,34 @@ func (m *MockConfigure) EXPECT() *MockConfigureMockRecorder {
 	return m.recorder
 }
 
+// Close mocks base method.
+func (m *MockConfigure) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockConfigureMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockConfigure)(nil).Close))
+}
+
+// Dump mocks base method.
+func (m *MockConfigure) Dump() *config.Node {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Dump")
+	ret0, _ := ret[0].(*config.Node)
+	return ret0
+}
+
+// Dump indicates an expected call of Dump.
+func (mr *MockConfigureMockRecorder) Dump() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Dump", reflect.TypeOf((*MockConfigure)(nil).Dump))
+}
+
 // Get mocks base method.
 func (m *MockConfigure) Get(arg0 string, arg1 interface{}) interface{} {
 	m.ctrl.T.Helper()
@@ -61,6 +91,20 @@ func (mr *MockConfigureMockRecorder) GetBool(arg0, arg1 interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBool", reflect.TypeOf((*MockConfigure)(nil).GetBool), arg0, arg1)
 }
 
+// GetDuration mocks base method.
+func (m *MockConfigure) GetDuration(arg0 string, arg1 time.Duration) time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDuration", arg0, arg1)
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// GetDuration indicates an expected call of GetDuration.
+func (mr *MockConfigureMockRecorder) GetDuration(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDuration", reflect.TypeOf((*MockConfigure)(nil).GetDuration), arg0, arg1)
+}
+
 // GetFloat32 mocks base method.
 func (m *MockConfigure) GetFloat32(arg0 string, arg1 float32) float32 {
 	m.ctrl.T.Helper()
@@ -131,6 +175,34 @@ func (mr *MockConfigureMockRecorder) GetInt64(arg0, arg1 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInt64", reflect.TypeOf((*MockConfigure)(nil).GetInt64), arg0, arg1)
 }
 
+// GetIntSlice mocks base method.
+func (m *MockConfigure) GetIntSlice(arg0 string, arg1 []int) []int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIntSlice", arg0, arg1)
+	ret0, _ := ret[0].([]int)
+	return ret0
+}
+
+// GetIntSlice indicates an expected call of GetIntSlice.
+func (mr *MockConfigureMockRecorder) GetIntSlice(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIntSlice", reflect.TypeOf((*MockConfigure)(nil).GetIntSlice), arg0, arg1)
+}
+
+// GetSizeInBytes mocks base method.
+func (m *MockConfigure) GetSizeInBytes(arg0 string, arg1 uint) uint {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSizeInBytes", arg0, arg1)
+	ret0, _ := ret[0].(uint)
+	return ret0
+}
+
+// GetSizeInBytes indicates an expected call of GetSizeInBytes.
+func (mr *MockConfigureMockRecorder) GetSizeInBytes(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSizeInBytes", reflect.TypeOf((*MockConfigure)(nil).GetSizeInBytes), arg0, arg1)
+}
+
 // GetString mocks base method.
 func (m *MockConfigure) GetString(arg0, arg1 string) string {
 	m.ctrl.T.Helper()
@@ -145,6 +217,62 @@ func (mr *MockConfigureMockRecorder) GetString(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetString", reflect.TypeOf((*MockConfigure)(nil).GetString), arg0, arg1)
 }
 
+// GetStringMap mocks base method.
+func (m *MockConfigure) GetStringMap(arg0 string, arg1 map[string]interface{}) map[string]interface{} {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStringMap", arg0, arg1)
+	ret0, _ := ret[0].(map[string]interface{})
+	return ret0
+}
+
+// GetStringMap indicates an expected call of GetStringMap.
+func (mr *MockConfigureMockRecorder) GetStringMap(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStringMap", reflect.TypeOf((*MockConfigure)(nil).GetStringMap), arg0, arg1)
+}
+
+// GetStringMapString mocks base method.
+func (m *MockConfigure) GetStringMapString(arg0 string, arg1 map[string]string) map[string]string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStringMapString", arg0, arg1)
+	ret0, _ := ret[0].(map[string]string)
+	return ret0
+}
+
+// GetStringMapString indicates an expected call of GetStringMapString.
+func (mr *MockConfigureMockRecorder) GetStringMapString(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStringMapString", reflect.TypeOf((*MockConfigure)(nil).GetStringMapString), arg0, arg1)
+}
+
+// GetStringSlice mocks base method.
+func (m *MockConfigure) GetStringSlice(arg0 string, arg1 []string) []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStringSlice", arg0, arg1)
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// GetStringSlice indicates an expected call of GetStringSlice.
+func (mr *MockConfigureMockRecorder) GetStringSlice(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStringSlice", reflect.TypeOf((*MockConfigure)(nil).GetStringSlice), arg0, arg1)
+}
+
+// GetTime mocks base method.
+func (m *MockConfigure) GetTime(arg0 string, arg1 time.Time) time.Time {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTime", arg0, arg1)
+	ret0, _ := ret[0].(time.Time)
+	return ret0
+}
+
+// GetTime indicates an expected call of GetTime.
+func (mr *MockConfigureMockRecorder) GetTime(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTime", reflect.TypeOf((*MockConfigure)(nil).GetTime), arg0, arg1)
+}
+
 // GetUint mocks base method.
 func (m *MockConfigure) GetUint(arg0 string, arg1 uint) uint {
 	m.ctrl.T.Helper()
@@ -201,6 +329,76 @@ func (mr *MockConfigureMockRecorder) IsExist(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsExist", reflect.TypeOf((*MockConfigure)(nil).IsExist), arg0)
 }
 
+// Keys mocks base method.
+func (m *MockConfigure) Keys(arg0 string) []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Keys", arg0)
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// Keys indicates an expected call of Keys.
+func (mr *MockConfigureMockRecorder) Keys(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Keys", reflect.TypeOf((*MockConfigure)(nil).Keys), arg0)
+}
+
+// ReloadFailures mocks base method.
+func (m *MockConfigure) ReloadFailures() uint64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReloadFailures")
+	ret0, _ := ret[0].(uint64)
+	return ret0
+}
+
+// ReloadFailures indicates an expected call of ReloadFailures.
+func (mr *MockConfigureMockRecorder) ReloadFailures() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReloadFailures", reflect.TypeOf((*MockConfigure)(nil).ReloadFailures))
+}
+
+// Set mocks base method.
+func (m *MockConfigure) Set(arg0 string, arg1 interface{}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Set", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Set indicates an expected call of Set.
+func (mr *MockConfigureMockRecorder) Set(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockConfigure)(nil).Set), arg0, arg1)
+}
+
+// Sub mocks base method.
+func (m *MockConfigure) Sub(arg0 string) config.Configure {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Sub", arg0)
+	ret0, _ := ret[0].(config.Configure)
+	return ret0
+}
+
+// Sub indicates an expected call of Sub.
+func (mr *MockConfigureMockRecorder) Sub(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sub", reflect.TypeOf((*MockConfigure)(nil).Sub), arg0)
+}
+
+// Subscribe mocks base method.
+func (m *MockConfigure) Subscribe(arg0 string, arg1 func([]config.Change)) func() {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", arg0, arg1)
+	ret0, _ := ret[0].(func())
+	return ret0
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockConfigureMockRecorder) Subscribe(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockConfigure)(nil).Subscribe), arg0, arg1)
+}
+
 // Unmarshal mocks base method.
 func (m *MockConfigure) Unmarshal(arg0 interface{}) error {
 	m.ctrl.T.Helper()
@@ -214,3 +412,31 @@ func (mr *MockConfigureMockRecorder) Unmarshal(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unmarshal", reflect.TypeOf((*MockConfigure)(nil).Unmarshal), arg0)
 }
+
+// UnmarshalKey mocks base method.
+func (m *MockConfigure) UnmarshalKey(arg0 string, arg1 interface{}) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnmarshalKey", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnmarshalKey indicates an expected call of UnmarshalKey.
+func (mr *MockConfigureMockRecorder) UnmarshalKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnmarshalKey", reflect.TypeOf((*MockConfigure)(nil).UnmarshalKey), arg0, arg1)
+}
+
+// WriteTo mocks base method.
+func (m *MockConfigure) WriteTo(arg0, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteTo", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteTo indicates an expected call of WriteTo.
+func (mr *MockConfigureMockRecorder) WriteTo(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteTo", reflect.TypeOf((*MockConfigure)(nil).WriteTo), arg0, arg1)
+}
@@ -1,6 +1,9 @@
 package config
 
 import (
+	"strings"
+	"time"
+
 	"github.com/wwwangxc/go-pkg/config/unmarshaler"
 )
 
@@ -15,14 +18,213 @@ func WithUnmarshaler(name string) LoadOption {
 }
 
 // WithWatchCallback with watch callback
+//
+// callback is called after the config is reloaded successfully, see WithReloadErrorCallback for failures.
 func WithWatchCallback(callback func(Configure)) LoadOption {
 	return func(c *configureImpl) {
 		c.watchCallback = callback
 	}
 }
 
+// WithWatchDebounce bursts of file events within the duration trigger a single reload
+//
+// default 100ms.
+func WithWatchDebounce(d time.Duration) LoadOption {
+	return func(c *configureImpl) {
+		c.watchDebounce = d
+	}
+}
+
+// WithPollInterval watch files by polling their content every interval instead of fsnotify
+//
+// for file systems where fsnotify is unreliable, e.g. NFS, overlay mounts.
+// polling is also the fallback when fsnotify is not available, default interval 5s.
+func WithPollInterval(interval time.Duration) LoadOption {
+	return func(c *configureImpl) {
+		if c.watcher != nil {
+			_ = c.watcher.Close()
+			c.watcher = nil
+		}
+
+		c.pollInterval = interval
+	}
+}
+
+// WithReloadValidator validate the candidate config before it is published
+//
+// validator runs on load and every reload, the load fail when validator return error,
+// the reload fail and the last good config stays active when validator return error.
+// see WithReloadErrorCallback to handle reload failures.
+func WithReloadValidator(validator func(Configure) error) LoadOption {
+	return func(c *configureImpl) {
+		c.reloadValidators = append(c.reloadValidators, validator)
+	}
+}
+
+// WithReloadErrorCallback callback when reload fail, e.g. read file fail, validate fail
+//
+// the last good config stays active when reload fail.
+func WithReloadErrorCallback(callback func(error)) LoadOption {
+	return func(c *configureImpl) {
+		c.reloadErrorCallback = callback
+	}
+}
+
+// WithOverlay overlay files on the base file
+//
+// overlay files are unmarshaled by the same unmarshaler as the base file
+// and deep merged in order, the later file takes precedence.
+// precedence: base file < overlay files < providers < environment variables < overrides
+func WithOverlay(paths ...string) LoadOption {
+	return func(c *configureImpl) {
+		c.overlays = append(c.overlays, paths...)
+	}
+}
+
+// WithOverride override value of key, has the highest precedence
+//
+// k support key1.key2.key3
+func WithOverride(k string, val interface{}) LoadOption {
+	return func(c *configureImpl) {
+		if c.overrides == nil {
+			c.overrides = map[string]interface{}{}
+		}
+		c.overrides[k] = val
+	}
+}
+
+// WithOverrideArgs override values by key=value arguments, e.g. command-line arguments
+//
+// values are parsed as yaml, e.g. 1, true, [a, b], arguments without '=' will be ignored.
+func WithOverrideArgs(args []string) LoadOption {
+	return func(c *configureImpl) {
+		for _, arg := range args {
+			kv := strings.SplitN(arg, "=", 2)
+			if len(kv) != 2 || kv[0] == "" {
+				continue
+			}
+
+			WithOverride(kv[0], parseValue(kv[1]))(c)
+		}
+	}
+}
+
+// WithProvider overlay config data of provider, e.g. etcd, redis
+//
+// providers are deep merged in order, the later provider takes precedence.
+// precedence: base file < overlay files < providers < environment variables < overrides
+func WithProvider(p Provider) LoadOption {
+	return func(c *configureImpl) {
+		c.providers = append(c.providers, p)
+	}
+}
+
+// WithEnv overlay environment variables with prefix
+//
+// e.g. APP_CLIENT_REDIS_MAX_IDLE will be mapped to client.redis.max_idle when prefix is APP.
+// see EnvOption for more details.
+func WithEnv(prefix string, opts ...EnvOption) LoadOption {
+	return func(c *configureImpl) {
+		c.envs = append(c.envs, newEnvSource(prefix, opts...))
+	}
+}
+
+// WithProfile activate profiles, the later profile takes precedence
+//
+// the section of the profile under the top level key profiles is merged over each file,
+// and the sibling file of the profile is merged over the base file, e.g. app-prod.yaml for app.yaml.
+// the top level key profiles is reserved, it is removed from the config when it is a map of sections.
+// default activate the profiles of environment variable CONFIG_PROFILE, e.g. CONFIG_PROFILE=prod
+func WithProfile(profiles ...string) LoadOption {
+	return func(c *configureImpl) {
+		c.profiles = profiles
+	}
+}
+
+// WithKeyProvider assign key provider to decrypt ENC(...) values
+//
+// default read base64 encoded key from environment variable CONFIG_SECRET_KEY,
+// or key file assigned by environment variable CONFIG_SECRET_KEY_FILE.
+func WithKeyProvider(p KeyProvider) LoadOption {
+	return func(c *configureImpl) {
+		c.keyProvider = p
+	}
+}
+
+// WithDiscardSetOnReload discard values set by Configure.Set when the config is reloaded
+//
+// default values set are kept over reloads.
+func WithDiscardSetOnReload() LoadOption {
+	return func(c *configureImpl) {
+		c.discardSetOnReload = true
+	}
+}
+
+// WithRedactKeys redact values of keys containing any of the patterns in Dump, case insensitive
+//
+// default password, passwd, secret, token, dsn. secret values are always redacted.
+func WithRedactKeys(patterns ...string) LoadOption {
+	return func(c *configureImpl) {
+		c.redactPatterns = append([]string{}, patterns...)
+	}
+}
+
+// WithJSONSchema validate the config against the inline json schema on load and every reload
+//
+// the config in any format is validated as json, e.g. yaml, toml.
+// the load fail when schema is invalid, violations are reported by *ValidationError with dotted keys.
+func WithJSONSchema(schema string) LoadOption {
+	return func(c *configureImpl) {
+		c.schemaInline = schema
+		c.schemaPath = ""
+	}
+}
+
+// WithJSONSchemaFile validate the config against the json schema file on load and every reload
+//
+// the schema file can be json or any registered format, e.g. yaml. see WithJSONSchema.
+func WithJSONSchemaFile(path string) LoadOption {
+	return func(c *configureImpl) {
+		c.schemaPath = path
+		c.schemaInline = ""
+	}
+}
+
+// WithCaseInsensitive match keys ignoring case, underscores and hyphens
+//
+// e.g. client.Redis.MaxIdle matches client.redis.max_idle, the exact key takes precedence.
+// works for getters, IsExist, UnmarshalKey, Sub and Dump.
+func WithCaseInsensitive() LoadOption {
+	return func(c *configureImpl) {
+		c.caseInsensitive = true
+	}
+}
+
+// WithKeyAliases rename keys by alias table of old keys => new keys, e.g. client.redis_pool => client.redis
+//
+// values of the old keys in config are moved to the new keys on load and every reload,
+// the value of the new key takes precedence, and deprecation warning is logged when old key exist.
+// reading by the old key gets the value of the new key.
+func WithKeyAliases(aliases map[string]string) LoadOption {
+	return func(c *configureImpl) {
+		if c.aliases == nil {
+			c.aliases = map[string]string{}
+		}
+
+		for old, newKey := range aliases {
+			c.aliases[old] = newKey
+		}
+	}
+}
+
+// withTest disable watching, the file watcher is closed to release the inotify instance
 func withTest() LoadOption {
 	return func(c *configureImpl) {
+		if c.watcher != nil {
+			_ = c.watcher.Close()
+		}
+
 		c.watcher = nil
+		c.pollInterval = 0
 	}
 }
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	profilesKey = "profiles"
+
+	// EnvProfile environment variable of the active profiles separated by comma, e.g. prod
+	//
+	// used when WithProfile is not assigned.
+	EnvProfile = "CONFIG_PROFILE"
+)
+
+// profilesFromEnv active profiles assigned by environment variable CONFIG_PROFILE
+func profilesFromEnv() []string {
+	var profiles []string
+	for _, profile := range strings.Split(os.Getenv(EnvProfile), ",") {
+		if profile = strings.TrimSpace(profile); profile != "" {
+			profiles = append(profiles, profile)
+		}
+	}
+
+	return profiles
+}
+
+// applyProfiles merge the sections of active profiles over data and remove the profiles key
+//
+// the profiles key is reserved for the profile sections, it is removed even if no profile active
+// when the value is a map of sections. the value which is not a map of sections is kept as it is
+// when no profile active. return true when the profiles key removed.
+func applyProfiles(data map[string]interface{}, profiles []string) (bool, error) {
+	val, exist := data[profilesKey]
+	if !exist {
+		return false, nil
+	}
+
+	sections, ok := profileSections(val)
+	if !ok {
+		if len(profiles) == 0 {
+			return false, nil
+		}
+		return false, errors.New("profiles must be map of profile sections")
+	}
+	delete(data, profilesKey)
+
+	for _, profile := range profiles {
+		if section, exist := sections[profile]; exist {
+			mergeMap(data, section)
+		}
+	}
+
+	return true, nil
+}
+
+// profileSections the sections by profile, return false when val is not a map of maps
+func profileSections(val interface{}) (map[string]map[string]interface{}, bool) {
+	m, ok := toStringMap(val)
+	if !ok {
+		return nil, false
+	}
+
+	sections := make(map[string]map[string]interface{}, len(m))
+	for profile, section := range m {
+		sectionData, ok := toStringMap(section)
+		if !ok {
+			return nil, false
+		}
+		sections[profile] = sectionData
+	}
+
+	return sections, true
+}
+
+// profilePath path of the profile sibling file, e.g. app-prod.yaml for app.yaml
+func profilePath(path, profile string) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s-%s%s", strings.TrimSuffix(path, ext), profile, ext)
+}
@@ -0,0 +1,151 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_configureImpl_profile(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		profiles []string
+		env      string
+		want     map[string]interface{}
+		wantErr  bool
+	}{
+		{
+			name: "no profile",
+			files: map[string]string{
+				"app.yaml": "a: 1\nprofiles:\n  prod:\n    a: 2\n",
+			},
+			want: map[string]interface{}{
+				"a": 1,
+			},
+		},
+		{
+			name: "profiles not sections kept",
+			files: map[string]string{
+				"app.yaml": "a: 1\nprofiles:\n  admin: [read, write]\n",
+			},
+			want: map[string]interface{}{
+				"a":        1,
+				"profiles": map[string]interface{}{"admin": []interface{}{"read", "write"}},
+			},
+		},
+		{
+			name: "profiles list kept",
+			files: map[string]string{
+				"app.yaml": "a: 1\nprofiles: [admin, guest]\n",
+			},
+			want: map[string]interface{}{
+				"a":        1,
+				"profiles": []interface{}{"admin", "guest"},
+			},
+		},
+		{
+			name: "profiles not sections with profile active",
+			files: map[string]string{
+				"app.yaml": "a: 1\nprofiles: [admin, guest]\n",
+			},
+			profiles: []string{"prod"},
+			wantErr:  true,
+		},
+		{
+			name: "profile section",
+			files: map[string]string{
+				"app.yaml": "a: 1\nb: 1\nprofiles:\n  dev:\n    a: 2\n  prod:\n    a: 3\n",
+			},
+			profiles: []string{"prod"},
+			want:     map[string]interface{}{"a": 3, "b": 1},
+		},
+		{
+			name: "profile file",
+			files: map[string]string{
+				"app.yaml":      "a: 1\nb: 1\n",
+				"app-prod.yaml": "a: 2\n",
+			},
+			profiles: []string{"prod"},
+			want:     map[string]interface{}{"a": 2, "b": 1},
+		},
+		{
+			name: "profile file not exist",
+			files: map[string]string{
+				"app.yaml": "a: 1\n",
+			},
+			profiles: []string{"prod"},
+			want:     map[string]interface{}{"a": 1},
+		},
+		{
+			name: "multiple profiles",
+			files: map[string]string{
+				"app.yaml":       "a: 1\nb: 1\nc: 1\nprofiles:\n  prod:\n    a: 2\n    b: 2\n  local:\n    a: 3\n",
+				"app-local.yaml": "c: 3\n",
+			},
+			profiles: []string{"prod", "local"},
+			want:     map[string]interface{}{"a": 3, "b": 2, "c": 3},
+		},
+		{
+			name: "profile from env",
+			files: map[string]string{
+				"app.yaml": "a: 1\nprofiles:\n  prod:\n    a: 2\n",
+			},
+			env:  "prod",
+			want: map[string]interface{}{"a": 2},
+		},
+		{
+			name: "invalid profile",
+			files: map[string]string{
+				"app.yaml": "a: 1\nprofiles:\n  prod: 2\n",
+			},
+			profiles: []string{"prod"},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, data := range tt.files {
+				writeFile(t, filepath.Join(dir, name), data)
+			}
+
+			if tt.env != "" {
+				os.Setenv(EnvProfile, tt.env)
+				defer os.Unsetenv(EnvProfile)
+			}
+
+			c := defaultConfigure(filepath.Join(dir, "app.yaml"))
+			defer c.Close()
+			withTest()(c)
+			if len(tt.profiles) > 0 {
+				WithProfile(tt.profiles...)(c)
+			}
+
+			err := c.Load()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("configureImpl.Load() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			got := map[string]interface{}{}
+			if err = c.Unmarshal(&got); err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("configureImpl.Unmarshal() = %v, error = %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func Test_profilesFromEnv(t *testing.T) {
+	os.Setenv(EnvProfile, "prod, local,")
+	defer os.Unsetenv(EnvProfile)
+
+	if got := profilesFromEnv(); !reflect.DeepEqual(got, []string{"prod", "local"}) {
+		t.Errorf("profilesFromEnv() = %v, want [prod local]", got)
+	}
+}
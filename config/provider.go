@@ -0,0 +1,83 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/wwwangxc/go-pkg/config/unmarshaler"
+)
+
+// providerRetryInterval interval to watch again after provider watch fail
+const providerRetryInterval = time.Second
+
+// Provider provides config data from somewhere other than local files, e.g. etcd, redis
+type Provider interface {
+
+	// Name unique name of the provider, used by cache key and logs, e.g. etcd:/app/config
+	Name() string
+
+	// Read read config data and unmarshal it by the unmarshaler of the config
+	Read(unmarshaler.Unmarshaler) (map[string]interface{}, error)
+
+	// Watch watch config data and call onChange when it changed
+	//
+	// Watch should block until ctx done, the config will watch again after
+	// provider retry interval when Watch return error.
+	Watch(ctx context.Context, onChange func()) error
+}
+
+// LoadProvider load config from provider
+//
+// config is reloaded when provider data changed, same as file config.
+func LoadProvider(p Provider, opts ...LoadOption) (Configure, error) {
+	return defaultLoader.Load("", append([]LoadOption{WithProvider(p)}, opts...)...)
+}
+
+// providerSource source of provider
+type providerSource struct {
+	provider    Provider
+	unmarshaler unmarshaler.Unmarshaler
+}
+
+func (p *providerSource) name() string {
+	return p.provider.Name()
+}
+
+func (p *providerSource) read(map[string]interface{}) ([]byte, map[string]interface{}, error) {
+	data, err := p.provider.Read(p.unmarshaler)
+	if err != nil {
+		return nil, nil, fmt.Errorf("provider read fail. provider:%s err:%w", p.provider.Name(), err)
+	}
+
+	return nil, data, nil
+}
+
+// watchProvider watch provider until the config closed
+func (c *configureImpl) watchProvider(p Provider, callback func(*configureImpl)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		<-c.done
+		cancel()
+	}()
+
+	for {
+		err := p.Watch(ctx, func() { c.onChanged(callback) })
+		if ctx.Err() != nil {
+			return
+		}
+
+		logErrorf("%s: provider watch fail. provider:%s err:%v\n", packageName, p.Name(), err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(providerRetryInterval):
+		}
+
+		// changes may be missed while not watching
+		c.onChanged(callback)
+	}
+}
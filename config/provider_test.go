@@ -0,0 +1,119 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/wwwangxc/go-pkg/config/unmarshaler"
+)
+
+type fakeProvider struct {
+	name    string
+	data    []byte
+	watches int32
+	changed chan struct{}
+	mu      sync.Mutex
+}
+
+func newFakeProvider(name, data string) *fakeProvider {
+	return &fakeProvider{
+		name:    name,
+		data:    []byte(data),
+		changed: make(chan struct{}, 1),
+	}
+}
+
+func (f *fakeProvider) Name() string {
+	return f.name
+}
+
+func (f *fakeProvider) Read(u unmarshaler.Unmarshaler) (map[string]interface{}, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	data := map[string]interface{}{}
+	err := u.Unmarshal(f.data, &data)
+	return data, err
+}
+
+func (f *fakeProvider) Watch(ctx context.Context, onChange func()) error {
+	// the first watch fail to test retry
+	if atomic.AddInt32(&f.watches, 1) == 1 {
+		return errors.New("watch fail")
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-f.changed:
+			onChange()
+		}
+	}
+}
+
+func (f *fakeProvider) set(data string) {
+	f.mu.Lock()
+	f.data = []byte(data)
+	f.mu.Unlock()
+	f.changed <- struct{}{}
+}
+
+func TestLoadProvider(t *testing.T) {
+	p := newFakeProvider("fake:TestLoadProvider", "value: 1\n")
+
+	c, err := LoadProvider(p, withTest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if got := c.GetInt("value", 0); got != 1 {
+		t.Errorf("configureImpl.GetInt() = %v, want %v", got, 1)
+	}
+
+	// wait for the retry of watch
+	if !waitFor(func() bool { return atomic.LoadInt32(&p.watches) == 2 }) {
+		t.Fatal("provider not watched again")
+	}
+
+	p.set("value: 2\n")
+	if !waitFor(func() bool { return c.GetInt("value", 0) == 2 }) {
+		t.Errorf("configureImpl.GetInt() = %v, want %v", c.GetInt("value", 0), 2)
+	}
+
+	if err = c.Close(); err != nil {
+		t.Errorf("configureImpl.Close() error = %v", err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case p.changed <- struct{}{}:
+	default:
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got := len(p.changed); got != 1 {
+		t.Errorf("provider watched after close")
+	}
+}
+
+func TestWithProvider(t *testing.T) {
+	p := newFakeProvider("fake:TestWithProvider", "subkey:\n  string_value: provider value\n")
+
+	c, err := Load("./testdata/config.yaml", WithProvider(p), withTest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if got := c.GetString("subkey.string_value", ""); got != "provider value" {
+		t.Errorf("configureImpl.GetString() = %v, want %v", got, "provider value")
+	}
+	if got := c.GetInt("subkey.int_value", 0); got != -1 {
+		t.Errorf("configureImpl.GetInt() = %v, want %v", got, -1)
+	}
+}
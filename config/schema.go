@@ -0,0 +1,206 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// inlineSchemaURL base url of the inline schema
+const inlineSchemaURL = "inline-schema.json"
+
+// compileSchema compile the json schema assigned by WithJSONSchema or WithJSONSchemaFile
+func (c *configureImpl) compileSchema() error {
+	if c.schema != nil || (c.schemaInline == "" && c.schemaPath == "") {
+		return nil
+	}
+
+	schemaURL := inlineSchemaURL
+	data := []byte(c.schemaInline)
+	if c.schemaPath != "" {
+		var err error
+		if data, err = readSchemaFile(c.schemaPath); err != nil {
+			return fmt.Errorf("%s: read json schema fail. file:%s err:%w", packageName, c.schemaPath, err)
+		}
+
+		schemaURL = (&url.URL{Scheme: "file", Path: filepath.ToSlash(absPath(c.schemaPath))}).String()
+	}
+
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(schemaURL, bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("%s: invalid json schema. err:%w", packageName, err)
+	}
+
+	schema, err := compiler.Compile(schemaURL)
+	if err != nil {
+		return fmt.Errorf("%s: invalid json schema. err:%w", packageName, err)
+	}
+
+	c.schema = schema
+	return nil
+}
+
+// readSchemaFile read schema file, schema in other format than json is converted into json, e.g. yaml
+func readSchemaFile(path string) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".json" {
+		return data, nil
+	}
+
+	u := detectUnmarshaler(path)
+	if u == nil {
+		return nil, fmt.Errorf("%w. unknown file extension:%s", ErrUnmarshalerNotExist, ext)
+	}
+
+	schema := map[string]interface{}{}
+	if err = u.Unmarshal(data, &schema); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(jsonValue(schema))
+}
+
+// validateSchema validate data against the json schema
+//
+// return *ValidationError with all violations, the keys are dotted keys, secret values are redacted.
+func (c *configureImpl) validateSchema(data map[string]interface{}, secrets map[string]interface{}) error {
+	if c.schema == nil {
+		return nil
+	}
+
+	instance := jsonValue(data)
+	raw, err := json.Marshal(instance)
+	if err != nil {
+		return fmt.Errorf("%s: marshal json fail. err:%w", packageName, err)
+	}
+
+	var doc interface{}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err = decoder.Decode(&doc); err != nil {
+		return fmt.Errorf("%s: unmarshal json fail. err:%w", packageName, err)
+	}
+
+	err = c.schema.Validate(doc)
+	schemaErr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return err
+	}
+
+	var errs []*FieldError
+	for _, cause := range schemaLeaves(schemaErr) {
+		key := pointerToKey(cause.InstanceLocation)
+		fieldErr := &FieldError{
+			Key:   key,
+			Rule:  fmt.Sprintf("schema %s: %s", cause.KeywordLocation, cause.Message),
+			Value: pointerValue(instance, cause.InstanceLocation),
+		}
+
+		for secret := range secrets {
+			if hasKeyPrefix(secret, key) {
+				fieldErr.Value = redactedValue
+				break
+			}
+		}
+
+		errs = append(errs, fieldErr)
+	}
+
+	sort.Slice(errs, func(i, j int) bool {
+		if errs[i].Key != errs[j].Key {
+			return errs[i].Key < errs[j].Key
+		}
+		return errs[i].Rule < errs[j].Rule
+	})
+
+	return &ValidationError{Errors: errs}
+}
+
+// schemaLeaves the innermost causes of the schema validation error
+func schemaLeaves(err *jsonschema.ValidationError) []*jsonschema.ValidationError {
+	if len(err.Causes) == 0 {
+		return []*jsonschema.ValidationError{err}
+	}
+
+	var leaves []*jsonschema.ValidationError
+	for _, cause := range err.Causes {
+		leaves = append(leaves, schemaLeaves(cause)...)
+	}
+
+	return leaves
+}
+
+// pointerToKey convert json pointer into dotted key, e.g. /client/service/0 => client.service.0
+func pointerToKey(pointer string) string {
+	var subkeys []string
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		if token != "" {
+			subkeys = append(subkeys, unescapePointer(token))
+		}
+	}
+
+	return strings.Join(subkeys, ".")
+}
+
+// pointerValue value of json pointer in v, nil when not exist
+func pointerValue(v interface{}, pointer string) interface{} {
+	val := v
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		if token == "" {
+			continue
+		}
+
+		switch node := val.(type) {
+		case map[string]interface{}:
+			val = node[unescapePointer(token)]
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil
+			}
+			val = node[i]
+		default:
+			return nil
+		}
+	}
+
+	return val
+}
+
+func unescapePointer(token string) string {
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+}
+
+// jsonValue convert nested maps with non-string keys into maps with string keys to be marshaled by json
+func jsonValue(v interface{}) interface{} {
+	if m, ok := toStringMap(v); ok {
+		ret := make(map[string]interface{}, len(m))
+		for k, val := range m {
+			ret[k] = jsonValue(val)
+		}
+		return ret
+	}
+
+	if items, ok := v.([]interface{}); ok {
+		ret := make([]interface{}, len(items))
+		for i, item := range items {
+			ret[i] = jsonValue(item)
+		}
+		return ret
+	}
+
+	return v
+}
@@ -0,0 +1,173 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testSchema = `{
+  "type": "object",
+  "required": ["client"],
+  "properties": {
+    "client": {
+      "type": "object",
+      "required": ["name"],
+      "properties": {
+        "name": {"type": "string"},
+        "max_idle": {"type": "integer", "minimum": 1},
+        "service": {
+          "type": "array",
+          "items": {"type": "object", "required": ["dsn"]}
+        }
+      }
+    }
+  }
+}`
+
+func Test_configureImpl_validateSchema(t *testing.T) {
+	tests := []struct {
+		name       string
+		file       string
+		data       string
+		schemaFile string
+		schema     string
+		wantKeys   []string
+		wantErr    bool
+	}{
+		{
+			name: "valid yaml",
+			file: "app.yaml",
+			data: "client:\n  name: app\n  max_idle: 10\n",
+		},
+		{
+			name:     "all violations",
+			file:     "app.yaml",
+			data:     "client:\n  max_idle: 0\n  service:\n    - name: redis\n",
+			wantKeys: []string{"client", "client.max_idle", "client.service.0"},
+		},
+		{
+			name:     "toml",
+			file:     "app.toml",
+			data:     "[client]\nname = 1\n",
+			wantKeys: []string{"client.name"},
+		},
+		{
+			name:       "yaml schema file",
+			file:       "app.yaml",
+			data:       "client:\n  name: 1\n",
+			schemaFile: "schema.yaml",
+			schema:     "type: object\nproperties:\n  client:\n    properties:\n      name:\n        type: string\n",
+			wantKeys:   []string{"client.name"},
+		},
+		{
+			name:   "profiles not validated",
+			file:   "app.yaml",
+			data:   "a: 1\nprofiles:\n  prod:\n    a: 2\n",
+			schema: `{"type": "object", "additionalProperties": false, "properties": {"a": {"type": "integer"}}}`,
+		},
+		{
+			name:    "invalid schema",
+			file:    "app.yaml",
+			data:    "client:\n  name: app\n",
+			schema:  `{"type": 1}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, tt.file)
+			writeFile(t, path, tt.data)
+
+			c := defaultConfigure(path)
+			defer c.Close()
+			withTest()(c)
+			switch {
+			case tt.schemaFile != "":
+				writeFile(t, filepath.Join(dir, tt.schemaFile), tt.schema)
+				WithJSONSchemaFile(filepath.Join(dir, tt.schemaFile))(c)
+			case tt.schema != "":
+				WithJSONSchema(tt.schema)(c)
+			default:
+				WithJSONSchema(testSchema)(c)
+			}
+
+			err := c.Load()
+			var validationErr *ValidationError
+			if tt.wantErr {
+				if err == nil || errors.As(err, &validationErr) {
+					t.Errorf("configureImpl.Load() error = %v, want invalid schema error", err)
+				}
+				return
+			}
+
+			if len(tt.wantKeys) == 0 {
+				if err != nil {
+					t.Errorf("configureImpl.Load() error = %v", err)
+				}
+				return
+			}
+
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("configureImpl.Load() error = %v, want *ValidationError", err)
+			}
+
+			var keys []string
+			for _, fieldErr := range validationErr.Errors {
+				keys = append(keys, fieldErr.Key)
+			}
+
+			if !reflect.DeepEqual(keys, tt.wantKeys) {
+				t.Errorf("configureImpl.Load() error = %v, want keys %v", err, tt.wantKeys)
+			}
+		})
+	}
+}
+
+func Test_configureImpl_validateSchema_reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	writeFile(t, path, "client:\n  name: app\n  max_idle: 10\n")
+
+	var reloadErr error
+	c := defaultConfigure(path)
+	defer c.Close()
+	withTest()(c)
+	WithJSONSchema(testSchema)(c)
+	WithReloadErrorCallback(func(err error) {
+		reloadErr = err
+	})(c)
+
+	if err := c.Load(); err != nil {
+		t.Fatalf("configureImpl.Load() error = %v", err)
+	}
+
+	writeFile(t, path, "client:\n  name: app\n  max_idle: 0\n")
+	c.Reload()
+
+	if reloadErr == nil || !strings.Contains(reloadErr.Error(), "client.max_idle") {
+		t.Errorf("reload error = %v, want client.max_idle violation", reloadErr)
+	}
+
+	if got := c.GetInt("client.max_idle", 0); got != 10 {
+		t.Errorf("configureImpl.GetInt() = %v, want 10", got)
+	}
+}
+
+func Test_pointerToKey(t *testing.T) {
+	tests := []struct {
+		pointer string
+		want    string
+	}{
+		{pointer: "", want: ""},
+		{pointer: "/client/service/0", want: "client.service.0"},
+		{pointer: "/a~1b/c~0d", want: "a/b.c~d"},
+	}
+	for _, tt := range tests {
+		if got := pointerToKey(tt.pointer); got != tt.want {
+			t.Errorf("pointerToKey(%q) = %v, want %v", tt.pointer, got, tt.want)
+		}
+	}
+}
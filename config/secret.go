@@ -0,0 +1,248 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	encPrefix = "ENC("
+	encSuffix = ")"
+
+	// EnvSecretKey environment variable of the base64 encoded key used by default
+	EnvSecretKey = "CONFIG_SECRET_KEY"
+
+	// EnvSecretKeyFile environment variable of the key file path used by default
+	// when EnvSecretKey is not set
+	EnvSecretKeyFile = "CONFIG_SECRET_KEY_FILE"
+
+	redactedValue = "******"
+)
+
+// KeyProvider provide the AES key to decrypt ENC(...) values
+//
+// key is requested on every load and reload when any encrypted value exists,
+// so the key can be rotated with the config.
+type KeyProvider interface {
+
+	// Key return AES-128, AES-192 or AES-256 key
+	Key() ([]byte, error)
+}
+
+// KeyProviderFunc function as KeyProvider
+type KeyProviderFunc func() ([]byte, error)
+
+// Key ...
+func (f KeyProviderFunc) Key() ([]byte, error) {
+	return f()
+}
+
+// NewKeyFileProvider read base64 encoded key from file
+func NewKeyFileProvider(path string) KeyProvider {
+	return KeyProviderFunc(func() ([]byte, error) {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read key file fail. file:%s err:%w", path, err)
+		}
+
+		return decodeKey(string(data))
+	})
+}
+
+// NewKeyEnvProvider read base64 encoded key from environment variable
+func NewKeyEnvProvider(name string) KeyProvider {
+	return KeyProviderFunc(func() ([]byte, error) {
+		val, exist := os.LookupEnv(name)
+		if !exist {
+			return nil, fmt.Errorf("key environment variable not set. env:%s", name)
+		}
+
+		return decodeKey(val)
+	})
+}
+
+// defaultKeyProvider read key from CONFIG_SECRET_KEY, or key file CONFIG_SECRET_KEY_FILE
+func defaultKeyProvider() KeyProvider {
+	return KeyProviderFunc(func() ([]byte, error) {
+		if _, exist := os.LookupEnv(EnvSecretKey); exist {
+			return NewKeyEnvProvider(EnvSecretKey).Key()
+		}
+
+		if path := os.Getenv(EnvSecretKeyFile); path != "" {
+			return NewKeyFileProvider(path).Key()
+		}
+
+		return nil, fmt.Errorf("key not assigned, set %s or %s, or use WithKeyProvider", EnvSecretKey, EnvSecretKeyFile)
+	})
+}
+
+// Encrypt encrypt plaintext by AES-GCM and return value like ENC(base64...)
+//
+// key must be 16, 24 or 32 bytes.
+func Encrypt(key []byte, plaintext string) (string, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	ciphertext := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encPrefix + base64.StdEncoding.EncodeToString(ciphertext) + encSuffix, nil
+}
+
+func decrypt(aead cipher.AEAD, value string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(value[len(encPrefix) : len(value)-len(encSuffix)])
+	if err != nil {
+		return "", err
+	}
+
+	if len(data) < aead.NonceSize() {
+		return "", errors.New("ciphertext too short")
+	}
+
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func decodeKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("key must be base64 encoded. err:%w", err)
+	}
+
+	return key, nil
+}
+
+func isEncrypted(val interface{}) bool {
+	s, ok := val.(string)
+	return ok && strings.HasPrefix(s, encPrefix) && strings.HasSuffix(s, encSuffix)
+}
+
+// secretDecrypter decrypt ENC(...) values of nested map in place
+//
+// error never contains the value, neither encrypted nor decrypted.
+type secretDecrypter struct {
+	keyProvider KeyProvider
+	aead        cipher.AEAD
+
+	// keys dotted keys of the decrypted values => encrypted values
+	keys map[string]interface{}
+}
+
+func (d *secretDecrypter) decryptMap(m map[string]interface{}, prefix string) error {
+	for k, v := range m {
+		val, err := d.decryptValue(v, joinKey(prefix, k))
+		if err != nil {
+			return err
+		}
+
+		m[k] = val
+	}
+
+	return nil
+}
+
+func (d *secretDecrypter) decryptValue(v interface{}, key string) (interface{}, error) {
+	if sub, ok := toStringMap(v); ok {
+		sub = copyMap(sub)
+		return sub, d.decryptMap(sub, key)
+	}
+
+	if items, ok := v.([]interface{}); ok {
+		ret := make([]interface{}, len(items))
+		for i, item := range items {
+			val, err := d.decryptValue(item, joinKey(key, strconv.Itoa(i)))
+			if err != nil {
+				return nil, err
+			}
+			ret[i] = val
+		}
+		return ret, nil
+	}
+
+	if !isEncrypted(v) {
+		return v, nil
+	}
+
+	if d.aead == nil {
+		secret, err := d.keyProvider.Key()
+		if err != nil {
+			return nil, fmt.Errorf("get secret key fail. err:%w", err)
+		}
+
+		if d.aead, err = newAEAD(secret); err != nil {
+			return nil, fmt.Errorf("invalid secret key. err:%w", err)
+		}
+	}
+
+	plaintext, err := decrypt(d.aead, v.(string))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt fail. key:%s err:%w", key, err)
+	}
+
+	if d.keys == nil {
+		d.keys = map[string]interface{}{}
+	}
+	d.keys[key] = v
+
+	return plaintext, nil
+}
+
+// decryptSecrets decrypt ENC(...) values of data in place
+//
+// return the dotted keys of the decrypted values => encrypted values.
+func (c *configureImpl) decryptSecrets(data map[string]interface{}) (map[string]interface{}, error) {
+	keyProvider := c.keyProvider
+	if keyProvider == nil {
+		keyProvider = defaultKeyProvider()
+	}
+
+	d := &secretDecrypter{keyProvider: keyProvider}
+	if err := d.decryptMap(data, ""); err != nil {
+		return nil, err
+	}
+
+	return d.keys, nil
+}
+
+// redact hide the decrypted values in validation error
+func (c *configureImpl) redact(err error) error {
+	var validationErr *ValidationError
+	if len(c.secrets) == 0 || !errors.As(err, &validationErr) {
+		return err
+	}
+
+	for _, fieldErr := range validationErr.Errors {
+		if _, exist := c.secrets[fieldErr.Key]; exist {
+			fieldErr.Value = redactedValue
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var testSecretKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestEncrypt(t *testing.T) {
+	got, err := Encrypt(testSecretKey, "password")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+
+	if !isEncrypted(got) {
+		t.Fatalf("Encrypt() = %v, want ENC(...)", got)
+	}
+
+	aead, _ := newAEAD(testSecretKey)
+	plaintext, err := decrypt(aead, got)
+	if err != nil || plaintext != "password" {
+		t.Errorf("decrypt() = %v, error = %v, want password", plaintext, err)
+	}
+
+	if _, err = Encrypt([]byte("short"), "password"); err == nil {
+		t.Errorf("Encrypt() error = nil, want invalid key error")
+	}
+}
+
+func TestNewKeyFileProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key")
+	writeFile(t, path, base64.StdEncoding.EncodeToString(testSecretKey)+"\n")
+
+	got, err := NewKeyFileProvider(path).Key()
+	if err != nil || string(got) != string(testSecretKey) {
+		t.Errorf("Key() = %s, error = %v, want %s", got, err, testSecretKey)
+	}
+
+	if _, err = NewKeyFileProvider(path + ".not_exist").Key(); err == nil {
+		t.Errorf("Key() error = nil, want read key file error")
+	}
+}
+
+func TestNewKeyEnvProvider(t *testing.T) {
+	os.Setenv("CONFIG_TEST_SECRET_KEY", base64.StdEncoding.EncodeToString(testSecretKey))
+	defer os.Unsetenv("CONFIG_TEST_SECRET_KEY")
+
+	got, err := NewKeyEnvProvider("CONFIG_TEST_SECRET_KEY").Key()
+	if err != nil || string(got) != string(testSecretKey) {
+		t.Errorf("Key() = %s, error = %v, want %s", got, err, testSecretKey)
+	}
+
+	if _, err = NewKeyEnvProvider("CONFIG_TEST_SECRET_KEY_NOT_EXIST").Key(); err == nil {
+		t.Errorf("Key() error = nil, want env not set error")
+	}
+}
+
+func Test_configureImpl_decryptSecrets(t *testing.T) {
+	password, _ := Encrypt(testSecretKey, "password")
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	writeFile(t, path, "client:\n  password: "+password+"\n  hosts: ["+password+"]\n  timeout: 1000\n")
+
+	keyProvider := KeyProviderFunc(func() ([]byte, error) {
+		return testSecretKey, nil
+	})
+
+	c := defaultConfigure(path)
+	defer c.Close()
+	withTest()(c)
+	WithKeyProvider(keyProvider)(c)
+	if err := c.Load(); err != nil {
+		t.Fatalf("configureImpl.Load() error = %v", err)
+	}
+
+	if got := c.GetString("client.password", ""); got != "password" {
+		t.Errorf("configureImpl.GetString() = %v, want password", got)
+	}
+
+	if got := c.GetStringSlice("client.hosts", nil); len(got) != 1 || got[0] != "password" {
+		t.Errorf("configureImpl.GetStringSlice() = %v, want [password]", got)
+	}
+
+	type clientConfig struct {
+		Password string `yaml:"password" validate:"min=10"`
+		Timeout  int    `yaml:"timeout"`
+	}
+
+	out := &clientConfig{}
+	err := c.UnmarshalKey("client", out)
+	if out.Password != "password" || out.Timeout != 1000 {
+		t.Errorf("configureImpl.UnmarshalKey() = %v, want decrypted password", out)
+	}
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || strings.Contains(err.Error(), "password,") ||
+		validationErr.Errors[0].Value != redactedValue {
+		t.Errorf("configureImpl.UnmarshalKey() error = %v, want redacted validation error", err)
+	}
+
+	// reload with wrong key keeps the last good config
+	c.keyProvider = KeyProviderFunc(func() ([]byte, error) {
+		return []byte("fedcba9876543210fedcba9876543210"), nil
+	})
+	c.Reload()
+	if c.ReloadFailures() != 1 || c.GetString("client.password", "") != "password" {
+		t.Errorf("configureImpl.Reload() with wrong key, failures = %v", c.ReloadFailures())
+	}
+}
+
+func Test_configureImpl_decryptSecrets_toml(t *testing.T) {
+	password, _ := Encrypt(testSecretKey, "password")
+	path := filepath.Join(t.TempDir(), "app.toml")
+	writeFile(t, path, "[[client.service]]\nname = 'redis'\npassword = '"+password+"'\n")
+
+	c := defaultConfigure(path)
+	defer c.Close()
+	withTest()(c)
+	WithKeyProvider(KeyProviderFunc(func() ([]byte, error) {
+		return testSecretKey, nil
+	}))(c)
+	if err := c.Load(); err != nil {
+		t.Fatalf("configureImpl.Load() error = %v", err)
+	}
+
+	if got := c.GetString("client.service[name=redis].password", ""); got != "password" {
+		t.Errorf("configureImpl.GetString() = %v, want password", got)
+	}
+
+	if got := c.Dump().Children["client"].Children["service"].Children["0"].Children["password"]; got.Value != redactedValue {
+		t.Errorf("configureImpl.Dump() = %v, want redacted", got.Value)
+	}
+}
+
+func Test_defaultKeyProvider(t *testing.T) {
+	os.Unsetenv(EnvSecretKey)
+	os.Unsetenv(EnvSecretKeyFile)
+	if _, err := defaultKeyProvider().Key(); err == nil {
+		t.Errorf("Key() error = nil, want key not assigned error")
+	}
+
+	path := filepath.Join(t.TempDir(), "key")
+	writeFile(t, path, base64.StdEncoding.EncodeToString(testSecretKey))
+
+	os.Setenv(EnvSecretKeyFile, path)
+	defer os.Unsetenv(EnvSecretKeyFile)
+	if got, err := defaultKeyProvider().Key(); err != nil || string(got) != string(testSecretKey) {
+		t.Errorf("Key() = %s, error = %v, want %s", got, err, testSecretKey)
+	}
+}
@@ -0,0 +1,344 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cast"
+	"gopkg.in/yaml.v3"
+
+	"github.com/wwwangxc/go-pkg/config/unmarshaler"
+)
+
+const includeKey = "include"
+
+// source is one layer of configuration data
+//
+// sources are deep merged in order, the later source takes precedence.
+type source interface {
+
+	// name source name, used by cache key and logs
+	name() string
+
+	// read read source and return raw data and unmarshaled data
+	//
+	// base is the merged data of the lower precedence sources, read only.
+	read(base map[string]interface{}) ([]byte, map[string]interface{}, error)
+}
+
+// fileSource local file source
+//
+// other files can be included by top level key include, e.g. include: [common.yaml, redis.yaml].
+// included files are merged in order, and the including file takes precedence.
+// relative paths are resolved against the directory of the including file.
+type fileSource struct {
+	path        string
+	unmarshaler unmarshaler.Unmarshaler
+
+	// profiles active profiles, the sections of profiles key are merged over the file in order
+	profiles []string
+
+	// optional missing file is treated as empty, e.g. app-prod.yaml
+	optional bool
+
+	// includes paths of the included files of the last read
+	includes []string
+
+	// origins dotted keys => origins of the values of the last read
+	origins map[string]*Origin
+
+	// interpolated dotted keys => original values of the interpolated values of the last read
+	interpolated map[string]interface{}
+}
+
+func newFileSource(path string, u unmarshaler.Unmarshaler) *fileSource {
+	return &fileSource{
+		path:        path,
+		unmarshaler: u,
+	}
+}
+
+func (f *fileSource) name() string {
+	if len(f.profiles) == 0 {
+		return f.path
+	}
+
+	return fmt.Sprintf("%s@%s", f.path, strings.Join(f.profiles, ","))
+}
+
+// read read the file and the included files
+//
+// raw data is absent when any file included or any profile section merged.
+func (f *fileSource) read(map[string]interface{}) ([]byte, map[string]interface{}, error) {
+	f.includes = nil
+	f.origins = map[string]*Origin{}
+	f.interpolated = map[string]interface{}{}
+	if _, err := os.Stat(f.path); f.optional && os.IsNotExist(err) {
+		return nil, map[string]interface{}{}, nil
+	}
+
+	raw, data, err := f.readFile(f.path, f.unmarshaler, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	f.traceProfiles(data)
+	merged, err := applyProfiles(data, f.profiles)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w. file:%s", err, f.path)
+	}
+
+	if merged {
+		raw = nil
+	}
+
+	return raw, data, nil
+}
+
+func (f *fileSource) readFile(path string, u unmarshaler.Unmarshaler, stack []string) ([]byte,
+	map[string]interface{}, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read file fail. file:%s err:%w", path, err)
+	}
+
+	unmarshaledData := map[string]interface{}{}
+	if err = u.Unmarshal(data, &unmarshaledData); err != nil {
+		return nil, nil, fmt.Errorf("unmarshal fail. file:%s err:%w", path, err)
+	}
+
+	includes, err := includePaths(path, unmarshaledData)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(includes) == 0 {
+		f.traceFile(path, u, data, unmarshaledData)
+		f.traceInterpolated(u, data, unmarshaledData)
+		return data, unmarshaledData, nil
+	}
+
+	stack = append(stack[:len(stack):len(stack)], resolvePath(path))
+	mergedData := map[string]interface{}{}
+	for _, include := range includes {
+		f.includes = append(f.includes, include)
+		for _, p := range stack {
+			if p == resolvePath(include) {
+				return nil, nil, fmt.Errorf("include cycle. file:%s include:%s", path, include)
+			}
+		}
+
+		includeUnmarshaler := detectUnmarshaler(include)
+		if includeUnmarshaler == nil {
+			includeUnmarshaler = u
+		}
+
+		_, includedData, err := f.readFile(include, includeUnmarshaler, stack)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		mergedData = mergeMap(mergedData, includedData)
+	}
+
+	f.traceFile(path, u, data, unmarshaledData)
+	f.traceInterpolated(u, data, unmarshaledData)
+	return nil, mergeMap(mergedData, unmarshaledData), nil
+}
+
+// includePaths remove the include key from data and return the included paths
+func includePaths(path string, data map[string]interface{}) ([]string, error) {
+	val, exist := data[includeKey]
+	if !exist {
+		return nil, nil
+	}
+	delete(data, includeKey)
+
+	var includes []string
+	switch v := val.(type) {
+	case string:
+		includes = []string{v}
+	case []interface{}:
+		for _, item := range v {
+			include, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("include must be string or list of string. file:%s", path)
+			}
+			includes = append(includes, include)
+		}
+	default:
+		return nil, fmt.Errorf("include must be string or list of string. file:%s", path)
+	}
+
+	for i, include := range includes {
+		if !filepath.IsAbs(include) {
+			includes[i] = filepath.Join(filepath.Dir(path), include)
+		}
+	}
+
+	return includes, nil
+}
+
+// overrideSource key/value overrides, e.g. command-line arguments
+type overrideSource struct {
+	values map[string]interface{}
+
+	// runtime values set by Configure.Set
+	runtime bool
+}
+
+func (o *overrideSource) name() string {
+	return fmt.Sprintf("override:%v", o.values)
+}
+
+func (o *overrideSource) read(map[string]interface{}) ([]byte, map[string]interface{}, error) {
+	keys := make([]string, 0, len(o.values))
+	for k := range o.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	data := map[string]interface{}{}
+	for _, k := range keys {
+		setToMap(data, strings.Split(k, "."), o.values[k])
+	}
+
+	return nil, data, nil
+}
+
+// setToMap set value into nested map by subkeys, missing sub maps will be created
+func setToMap(m map[string]interface{}, subkeys []string, val interface{}) {
+	if len(subkeys) == 0 {
+		return
+	}
+
+	if len(subkeys) == 1 {
+		m[subkeys[0]] = val
+		return
+	}
+
+	sub, ok := toStringMap(m[subkeys[0]])
+	if !ok {
+		sub = map[string]interface{}{}
+	}
+
+	setToMap(sub, subkeys[1:], val)
+	m[subkeys[0]] = sub
+}
+
+// mergeMap deep merge src into dst
+//
+// nested maps are merged recursively, other values in src overwrite dst.
+func mergeMap(dst, src map[string]interface{}) map[string]interface{} {
+	if dst == nil {
+		dst = map[string]interface{}{}
+	}
+
+	for k, srcVal := range src {
+		srcMap, srcIsMap := toStringMap(srcVal)
+		dstMap, dstIsMap := toStringMap(dst[k])
+		if srcIsMap && dstIsMap {
+			dst[k] = mergeMap(copyMap(dstMap), srcMap)
+			continue
+		}
+
+		if srcIsMap {
+			dst[k] = mergeMap(nil, srcMap)
+			continue
+		}
+
+		dst[k] = srcVal
+	}
+
+	return dst
+}
+
+// parseValue parse string value as yaml scalar or flow collection
+//
+// the value is kept as string when parsing fail or it can not round trip, e.g. 0123.
+func parseValue(s string) interface{} {
+	var val interface{}
+	if err := yaml.Unmarshal([]byte(s), &val); err != nil {
+		return s
+	}
+
+	switch val.(type) {
+	case bool, int, float64:
+		if cast.ToString(val) == s {
+			return val
+		}
+	case []interface{}, map[string]interface{}:
+		if strings.HasPrefix(s, "[") || strings.HasPrefix(s, "{") {
+			return val
+		}
+	}
+
+	return s
+}
+
+// layer data read from the source, kept to merge the values set at runtime without reading again
+type layer struct {
+	source source
+	raw    []byte
+	data   map[string]interface{}
+
+	// origins dotted keys of leaf values => origins at the time of read
+	origins map[string]*Origin
+
+	// interpolated dotted keys of the interpolated values => original values, e.g. ${DB_PASSWORD}
+	interpolated map[string]interface{}
+}
+
+// copyValue deep copy nested maps and slices
+//
+// slices of maps are normalized to []interface{}, e.g. arrays of tables of toml.
+func copyValue(v interface{}) interface{} {
+	if maps, ok := v.([]map[string]interface{}); ok {
+		ret := make([]interface{}, len(maps))
+		for i, m := range maps {
+			ret[i] = copyValue(m)
+		}
+		return ret
+	}
+
+	if m, ok := toStringMap(v); ok {
+		ret := make(map[string]interface{}, len(m))
+		for k, val := range m {
+			ret[k] = copyValue(val)
+		}
+		return ret
+	}
+
+	if items, ok := v.([]interface{}); ok {
+		ret := make([]interface{}, len(items))
+		for i, item := range items {
+			ret[i] = copyValue(item)
+		}
+		return ret
+	}
+
+	return v
+}
+
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	ret := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		ret[k] = v
+	}
+	return ret
+}
+
+func toStringMap(val interface{}) (map[string]interface{}, bool) {
+	switch m := val.(type) {
+	case map[string]interface{}:
+		return m, true
+	case map[interface{}]interface{}:
+		return cast.ToStringMap(m), true
+	default:
+		return nil, false
+	}
+}
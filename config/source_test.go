@@ -0,0 +1,263 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/wwwangxc/go-pkg/config/unmarshaler"
+)
+
+func Test_mergeMap(t *testing.T) {
+	type args struct {
+		dst map[string]interface{}
+		src map[string]interface{}
+	}
+	tests := []struct {
+		name string
+		args args
+		want map[string]interface{}
+	}{
+		{
+			name: "nil dst",
+			args: args{
+				src: map[string]interface{}{"a": 1},
+			},
+			want: map[string]interface{}{"a": 1},
+		},
+		{
+			name: "overwrite value",
+			args: args{
+				dst: map[string]interface{}{"a": 1, "b": 2},
+				src: map[string]interface{}{"a": 3},
+			},
+			want: map[string]interface{}{"a": 3, "b": 2},
+		},
+		{
+			name: "deep merge",
+			args: args{
+				dst: map[string]interface{}{
+					"a": map[string]interface{}{"b": 1, "c": 2},
+				},
+				src: map[string]interface{}{
+					"a": map[interface{}]interface{}{"c": 3, "d": 4},
+				},
+			},
+			want: map[string]interface{}{
+				"a": map[string]interface{}{"b": 1, "c": 3, "d": 4},
+			},
+		},
+		{
+			name: "map overwrite scalar",
+			args: args{
+				dst: map[string]interface{}{"a": 1},
+				src: map[string]interface{}{
+					"a": map[string]interface{}{"b": 1},
+				},
+			},
+			want: map[string]interface{}{
+				"a": map[string]interface{}{"b": 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeMap(tt.args.dst, tt.args.src); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeMap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_overrideSource_read(t *testing.T) {
+	s := &overrideSource{
+		values: map[string]interface{}{
+			"a":     1,
+			"b.c":   "2",
+			"b.d.e": true,
+		},
+	}
+
+	want := map[string]interface{}{
+		"a": 1,
+		"b": map[string]interface{}{
+			"c": "2",
+			"d": map[string]interface{}{"e": true},
+		},
+	}
+
+	_, got, err := s.read(nil)
+	if err != nil {
+		t.Errorf("overrideSource.read() error = %v", err)
+		return
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("overrideSource.read() = %v, want %v", got, want)
+	}
+}
+
+func Test_configureImpl_layered(t *testing.T) {
+	c := defaultConfigure("./testdata/config.yaml")
+	defer c.Close()
+	c.unmarshaler = &unmarshaler.YAML{}
+	WithOverlay("./testdata/overlay.yaml")(c)
+	WithOverride("subkey.string_value", "override value")(c)
+	WithOverrideArgs([]string{"int_value=100", "invalid"})(c)
+	if err := c.Load(); err != nil {
+		t.Errorf("configureImpl.Load() error = %v", err)
+		return
+	}
+
+	tests := []struct {
+		name string
+		k    string
+		want interface{}
+	}{
+		{
+			name: "base value",
+			k:    "bool_value",
+			want: true,
+		},
+		{
+			name: "overlay value",
+			k:    "string_value",
+			want: "overlay string value",
+		},
+		{
+			name: "overlay subkey value",
+			k:    "subkey.int_value",
+			want: -100,
+		},
+		{
+			name: "overlay new subkey value",
+			k:    "subkey.overlay_value",
+			want: "overlay value",
+		},
+		{
+			name: "base subkey value",
+			k:    "subkey.bool_value",
+			want: true,
+		},
+		{
+			name: "override value",
+			k:    "subkey.string_value",
+			want: "override value",
+		},
+		{
+			name: "override args value",
+			k:    "int_value",
+			want: 100,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.Get(tt.k, nil); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("configureImpl.Get() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	out := struct {
+		StringValue string `yaml:"string_value"`
+		IntValue    int    `yaml:"int_value"`
+		Subkey      struct {
+			StringValue string `yaml:"string_value"`
+			IntValue    int    `yaml:"int_value"`
+		} `yaml:"subkey"`
+	}{}
+	if err := c.Unmarshal(&out); err != nil {
+		t.Errorf("configureImpl.Unmarshal() error = %v", err)
+		return
+	}
+	if out.StringValue != "overlay string value" || out.IntValue != 100 ||
+		out.Subkey.StringValue != "override value" || out.Subkey.IntValue != -100 {
+		t.Errorf("configureImpl.Unmarshal() = %+v", out)
+	}
+}
+
+func Test_fileSource_read_include(t *testing.T) {
+	tests := []struct {
+		name         string
+		files        map[string]string
+		want         map[string]interface{}
+		wantIncludes []string
+		wantErr      bool
+	}{
+		{
+			name: "include",
+			files: map[string]string{
+				"app.yaml":          "include: [common/redis.yaml, common/mysql.json]\nclient:\n  redis:\n    max_idle: 20\n",
+				"common/redis.yaml": "include: ../base.yaml\nclient:\n  redis:\n    max_idle: 10\n    max_active: 100\n",
+				"common/mysql.json": `{"client": {"mysql": {"max_idle": 10}}}`,
+				"base.yaml":         "machine_id: 1\nclient:\n  redis:\n    max_active: 50\n",
+			},
+			want: map[string]interface{}{
+				"machine_id": 1,
+				"client": map[string]interface{}{
+					"redis": map[string]interface{}{"max_idle": 20, "max_active": 100},
+					"mysql": map[string]interface{}{"max_idle": float64(10)},
+				},
+			},
+			wantIncludes: []string{"common/redis.yaml", "base.yaml", "common/mysql.json"},
+		},
+		{
+			name: "include cycle",
+			files: map[string]string{
+				"app.yaml":    "include: [common.yaml]\n",
+				"common.yaml": "include: [app.yaml]\n",
+			},
+			wantErr: true,
+		},
+		{
+			name: "included file not exist",
+			files: map[string]string{
+				"app.yaml": "include: [not_exist.yaml]\n",
+			},
+			wantIncludes: []string{"not_exist.yaml"},
+			wantErr:      true,
+		},
+		{
+			name: "invalid include",
+			files: map[string]string{
+				"app.yaml": "include: {a: b}\n",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, data := range tt.files {
+				writeFile(t, filepath.Join(dir, name), data)
+			}
+
+			f := newFileSource(filepath.Join(dir, "app.yaml"), &unmarshaler.YAML{})
+			raw, got, err := f.read(nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("fileSource.read() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			var wantIncludes []string
+			for _, include := range tt.wantIncludes {
+				wantIncludes = append(wantIncludes, filepath.Join(dir, include))
+			}
+
+			if len(tt.wantIncludes) > 0 && !reflect.DeepEqual(f.includes, wantIncludes) {
+				t.Errorf("fileSource.includes = %v, want %v", f.includes, wantIncludes)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if raw != nil {
+				t.Errorf("fileSource.read() raw = %s, want nil", raw)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fileSource.read() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package config
+
+import (
+	"time"
+)
+
+// subConfigure view of the sub tree of configureImpl
+//
+// all methods are delegated to the root with key prefixed, so the view
+// shares lock and data with the root and stays current after reload.
+type subConfigure struct {
+	root   *configureImpl
+	prefix string
+}
+
+func newSubConfigure(root *configureImpl, prefix string) *subConfigure {
+	return &subConfigure{
+		root:   root,
+		prefix: prefix,
+	}
+}
+
+// Unmarshal unmarshal the sub tree
+func (s *subConfigure) Unmarshal(out interface{}) error {
+	return s.root.UnmarshalKey(s.prefix, out)
+}
+
+// UnmarshalKey unmarshal the sub tree of key
+func (s *subConfigure) UnmarshalKey(k string, out interface{}) error {
+	return s.root.UnmarshalKey(s.key(k), out)
+}
+
+// IsExist check the key exist
+func (s *subConfigure) IsExist(k string) bool {
+	return s.root.IsExist(s.key(k))
+}
+
+// Get get value by key
+func (s *subConfigure) Get(k string, defaultVal interface{}) interface{} {
+	return s.root.Get(s.key(k), defaultVal)
+}
+
+// GetString get string value by key
+func (s *subConfigure) GetString(k string, defaultVal string) string {
+	return s.root.GetString(s.key(k), defaultVal)
+}
+
+// GetBool get bool value by key
+func (s *subConfigure) GetBool(k string, defaultVal bool) bool {
+	return s.root.GetBool(s.key(k), defaultVal)
+}
+
+// GetInt get int value by key
+func (s *subConfigure) GetInt(k string, defaultVal int) int {
+	return s.root.GetInt(s.key(k), defaultVal)
+}
+
+// GetInt32 get int32 value by key
+func (s *subConfigure) GetInt32(k string, defaultVal int32) int32 {
+	return s.root.GetInt32(s.key(k), defaultVal)
+}
+
+// GetInt64 get int64 value by key
+func (s *subConfigure) GetInt64(k string, defaultVal int64) int64 {
+	return s.root.GetInt64(s.key(k), defaultVal)
+}
+
+// GetUint get uint value by key
+func (s *subConfigure) GetUint(k string, defaultVal uint) uint {
+	return s.root.GetUint(s.key(k), defaultVal)
+}
+
+// GetUint32 get uint32 value by key
+func (s *subConfigure) GetUint32(k string, defaultVal uint32) uint32 {
+	return s.root.GetUint32(s.key(k), defaultVal)
+}
+
+// GetUint64 get uint64 value by key
+func (s *subConfigure) GetUint64(k string, defaultVal uint64) uint64 {
+	return s.root.GetUint64(s.key(k), defaultVal)
+}
+
+// GetFloat32 get float32 value by key
+func (s *subConfigure) GetFloat32(k string, defaultVal float32) float32 {
+	return s.root.GetFloat32(s.key(k), defaultVal)
+}
+
+// GetFloat64 get float64 value by key
+func (s *subConfigure) GetFloat64(k string, defaultVal float64) float64 {
+	return s.root.GetFloat64(s.key(k), defaultVal)
+}
+
+// GetDuration get time.Duration value by key
+func (s *subConfigure) GetDuration(k string, defaultVal time.Duration) time.Duration {
+	return s.root.GetDuration(s.key(k), defaultVal)
+}
+
+// GetTime get time.Time value by key
+func (s *subConfigure) GetTime(k string, defaultVal time.Time) time.Time {
+	return s.root.GetTime(s.key(k), defaultVal)
+}
+
+// GetStringSlice get []string value by key
+func (s *subConfigure) GetStringSlice(k string, defaultVal []string) []string {
+	return s.root.GetStringSlice(s.key(k), defaultVal)
+}
+
+// GetIntSlice get []int value by key
+func (s *subConfigure) GetIntSlice(k string, defaultVal []int) []int {
+	return s.root.GetIntSlice(s.key(k), defaultVal)
+}
+
+// GetStringMap get map[string]interface{} value by key
+func (s *subConfigure) GetStringMap(k string, defaultVal map[string]interface{}) map[string]interface{} {
+	return s.root.GetStringMap(s.key(k), defaultVal)
+}
+
+// GetStringMapString get map[string]string value by key
+func (s *subConfigure) GetStringMapString(k string, defaultVal map[string]string) map[string]string {
+	return s.root.GetStringMapString(s.key(k), defaultVal)
+}
+
+// GetSizeInBytes get size in bytes by key
+func (s *subConfigure) GetSizeInBytes(k string, defaultVal uint) uint {
+	return s.root.GetSizeInBytes(s.key(k), defaultVal)
+}
+
+// Sub get the view of sub tree by key
+func (s *subConfigure) Sub(k string) Configure {
+	return newSubConfigure(s.root, s.key(k))
+}
+
+// Subscribe subscribe changes of keys with prefix between reloads
+func (s *subConfigure) Subscribe(prefix string, callback func([]Change)) func() {
+	return s.root.Subscribe(s.key(prefix), callback)
+}
+
+// ReloadFailures count of reload failures
+func (s *subConfigure) ReloadFailures() uint64 {
+	return s.root.ReloadFailures()
+}
+
+// Close do nothing, the view does not own the lifecycle of the root
+func (s *subConfigure) Close() error {
+	return nil
+}
+
+// Set set value of key at runtime
+func (s *subConfigure) Set(k string, val interface{}) error {
+	return s.root.Set(s.key(k), val)
+}
+
+// WriteTo write the sub tree into file by the format
+func (s *subConfigure) WriteTo(path, format string) error {
+	return s.root.writeKey(s.prefix, path, format)
+}
+
+// Keys list keys of leaf values under prefix, keys are relative to the sub tree root
+func (s *subConfigure) Keys(prefix string) []string {
+	return s.root.keys(s.prefix, prefix)
+}
+
+// Dump dump the sub tree with origins, return nil when the sub tree not exist
+func (s *subConfigure) Dump() *Node {
+	return s.root.dumpKey(s.prefix)
+}
+
+// key convert the relative key into the key of root
+func (s *subConfigure) key(k string) string {
+	if k == "" {
+		return s.prefix
+	}
+
+	if s.prefix == "" {
+		return k
+	}
+
+	return s.prefix + "." + k
+}
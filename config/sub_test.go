@@ -0,0 +1,104 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_subConfigure(t *testing.T) {
+	c := defaultConfigure("./testdata/config.yaml")
+	defer c.Close()
+	_ = c.Load()
+
+	sub := c.Sub("subkey")
+	if got := sub.GetString("string_value", "default value"); got != "string value" {
+		t.Errorf("subConfigure.GetString() = %v, want %v", got, "string value")
+	}
+	if got := sub.GetInt("int_value", 999); got != -1 {
+		t.Errorf("subConfigure.GetInt() = %v, want %v", got, -1)
+	}
+	if got := sub.IsExist("not exist key"); got {
+		t.Errorf("subConfigure.IsExist() = %v, want %v", got, false)
+	}
+	if got := sub.GetString("not exist key", "default value"); got != "default value" {
+		t.Errorf("subConfigure.GetString() = %v, want %v", got, "default value")
+	}
+
+	out := struct {
+		StringValue string  `yaml:"string_value"`
+		Float64     float64 `yaml:"float64_value"`
+	}{}
+	if err := sub.Unmarshal(&out); err != nil {
+		t.Errorf("subConfigure.Unmarshal() error = %v", err)
+		return
+	}
+	if out.StringValue != "string value" || out.Float64 != 2.22 {
+		t.Errorf("subConfigure.Unmarshal() = %+v", out)
+	}
+
+	if err := c.Sub("not exist key").Unmarshal(&out); err == nil {
+		t.Errorf("subConfigure.Unmarshal() error = %v, wantErr %v", err, true)
+	}
+}
+
+func Test_subConfigure_Sub(t *testing.T) {
+	c := defaultConfigure("./testdata/config.yaml")
+	defer c.Close()
+	_ = c.Load()
+
+	tests := []struct {
+		name string
+		sub  Configure
+		k    string
+		want string
+	}{
+		{
+			name: "empty prefix",
+			sub:  c.Sub(""),
+			k:    "string_value",
+			want: "string value",
+		},
+		{
+			name: "nested sub",
+			sub:  c.Sub("subkey").Sub(""),
+			k:    "string_value",
+			want: "string value",
+		},
+		{
+			name: "empty key",
+			sub:  c.Sub("subkey").Sub("string_value"),
+			k:    "",
+			want: "string value",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sub.GetString(tt.k, "default value"); got != tt.want {
+				t.Errorf("subConfigure.GetString() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_subConfigure_reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := ioutil.WriteFile(path, []byte("client:\n  redis:\n    max_idle: 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := defaultConfigure(path)
+	defer c.Close()
+	_ = c.Load()
+	sub := c.Sub("client.redis")
+
+	if err := ioutil.WriteFile(path, []byte("client:\n  redis:\n    max_idle: 2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c.Reload()
+
+	if got := sub.GetInt("max_idle", 0); !reflect.DeepEqual(got, 2) {
+		t.Errorf("subConfigure.GetInt() = %v, want %v", got, 2)
+	}
+}
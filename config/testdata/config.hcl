@@ -0,0 +1,9 @@
+string_value = "string value"
+int_value = -1
+bool_value = true
+env_value = "${CONFIG_FORMAT_ENV}"
+
+subkey {
+  int_value = -1
+  string_slice_value = ["a", "b"]
+}
@@ -0,0 +1,134 @@
+package unmarshaler
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const dotenvSeparator = "__"
+
+func init() {
+	Register(&Dotenv{}, ".env")
+}
+
+// Dotenv dotenv unmarshaler
+//
+// key is lower cased and split by double underscore,
+// e.g. CLIENT__REDIS__MAX_IDLE is mapped to client.redis.max_idle.
+//
+// supported syntax:
+//
+//	# comment
+//	export KEY=value
+//	KEY=value # comment
+//	KEY='single quoted value'
+//	KEY="double quoted value\n"
+type Dotenv struct{}
+
+// Unmarshal unmarshal by dotenv
+func (d *Dotenv) Unmarshal(in []byte, out interface{}) error {
+	expanded, err := expandEnv(string(in))
+	if err != nil {
+		return err
+	}
+
+	return d.Decode([]byte(expanded), out)
+}
+
+// Decode unmarshal by dotenv without expanding environment variables
+func (d *Dotenv) Decode(in []byte, out interface{}) error {
+	m := map[string]interface{}{}
+	scanner := bufio.NewScanner(bytes.NewReader(in))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		kv := strings.SplitN(strings.TrimPrefix(line, "export "), "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("dotenv: line %d: missing '='", n)
+		}
+
+		subkeys := splitKey(strings.ToLower(kv[0]), dotenvSeparator)
+		if len(subkeys) == 0 {
+			return fmt.Errorf("dotenv: line %d: empty key", n)
+		}
+
+		val, err := parseDotenvValue(strings.TrimSpace(kv[1]))
+		if err != nil {
+			return fmt.Errorf("dotenv: line %d: %w", n, err)
+		}
+
+		if err = setNested(m, subkeys, val); err != nil {
+			return fmt.Errorf("dotenv: line %d: %w", n, err)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return decodeMap(m, out)
+}
+
+// Name unmarshal name
+func (d *Dotenv) Name() string {
+	return "dotenv"
+}
+
+// Marshal marshal by dotenv
+func (d *Dotenv) Marshal(in interface{}) ([]byte, error) {
+	m, err := toMap(in)
+	if err != nil {
+		return nil, err
+	}
+
+	leaves := map[string]string{}
+	flattenMap(m, "", dotenvSeparator, leaves)
+
+	buf := &bytes.Buffer{}
+	for _, k := range sortedKeys(leaves) {
+		v := leaves[k]
+		if strings.ContainsAny(v, " #'\"\\\n\t") {
+			v = strconv.Quote(v)
+		}
+
+		fmt.Fprintf(buf, "%s=%s\n", strings.ToUpper(k), v)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// parseDotenvValue unquote value and strip inline comment
+func parseDotenvValue(s string) (interface{}, error) {
+	switch {
+	case strings.HasPrefix(s, "'"):
+		end := strings.Index(s[1:], "'")
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated single quote")
+		}
+		return s[1 : end+1], nil
+	case strings.HasPrefix(s, "\""):
+		for i := 1; i < len(s); i++ {
+			if s[i] == '\\' {
+				i++
+				continue
+			}
+
+			if s[i] == '"' {
+				return strconv.Unquote(s[:i+1])
+			}
+		}
+		return nil, fmt.Errorf("unterminated double quote")
+	}
+
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+
+	return parseScalar(s), nil
+}
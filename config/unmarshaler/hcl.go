@@ -0,0 +1,94 @@
+package unmarshaler
+
+import (
+	"encoding/json"
+
+	"github.com/hashicorp/hcl"
+)
+
+func init() {
+	Register(&HCL{})
+}
+
+// HCL hcl unmarshaler
+//
+// blocks are merged into nested map, e.g. client { redis { max_idle = 10 } }
+// is mapped to client.redis.max_idle.
+type HCL struct{}
+
+// Unmarshal unmarshal by hcl
+func (h *HCL) Unmarshal(in []byte, out interface{}) error {
+	expanded, err := expandEnv(string(in))
+	if err != nil {
+		return err
+	}
+
+	return h.Decode([]byte(expanded), out)
+}
+
+// Decode unmarshal by hcl without expanding environment variables
+func (h *HCL) Decode(in []byte, out interface{}) error {
+	m := map[string]interface{}{}
+	if err := hcl.Unmarshal(in, &m); err != nil {
+		return err
+	}
+
+	m, _ = normalizeHCL(m).(map[string]interface{})
+	return decodeMap(m, out)
+}
+
+// Name unmarshal name
+func (h *HCL) Name() string {
+	return "hcl"
+}
+
+// Marshal marshal by hcl, the output is json which is valid hcl
+func (h *HCL) Marshal(in interface{}) ([]byte, error) {
+	m, err := toMap(in)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(m, "", "  ")
+}
+
+// normalizeHCL merge list of blocks decoded by hcl into map
+func normalizeHCL(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, item := range val {
+			val[k] = normalizeHCL(item)
+		}
+		return val
+	case []map[string]interface{}:
+		m := map[string]interface{}{}
+		for _, block := range val {
+			for k, item := range block {
+				m[k] = mergeHCL(m[k], normalizeHCL(item))
+			}
+		}
+		return m
+	case []interface{}:
+		for i, item := range val {
+			val[i] = normalizeHCL(item)
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+// mergeHCL merge blocks with the same name
+func mergeHCL(dst, src interface{}) interface{} {
+	dstMap, dstIsMap := dst.(map[string]interface{})
+	srcMap, srcIsMap := src.(map[string]interface{})
+	if !dstIsMap || !srcIsMap {
+		return src
+	}
+
+	for k, v := range srcMap {
+		dstMap[k] = mergeHCL(dstMap[k], v)
+	}
+
+	return dstMap
+}
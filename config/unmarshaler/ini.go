@@ -0,0 +1,88 @@
+package unmarshaler
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gopkg.in/ini.v1"
+)
+
+func init() {
+	Register(&INI{})
+}
+
+// INI ini unmarshaler
+//
+// keys of the default section are top level keys, section name is split by dot,
+// e.g. key max_idle in section [client.redis] is mapped to client.redis.max_idle.
+type INI struct{}
+
+// Unmarshal unmarshal by ini
+func (i *INI) Unmarshal(in []byte, out interface{}) error {
+	expanded, err := expandEnv(string(in))
+	if err != nil {
+		return err
+	}
+
+	return i.Decode([]byte(expanded), out)
+}
+
+// Decode unmarshal by ini without expanding environment variables
+func (i *INI) Decode(in []byte, out interface{}) error {
+	f, err := ini.LoadSources(ini.LoadOptions{}, in)
+	if err != nil {
+		return err
+	}
+
+	m := map[string]interface{}{}
+	for _, section := range f.Sections() {
+		var subkeys []string
+		if section.Name() != ini.DefaultSection {
+			subkeys = splitKey(section.Name(), ".")
+		}
+
+		for _, key := range section.Keys() {
+			if err = setNested(m, append(subkeys, key.Name()), parseScalar(key.Value())); err != nil {
+				return fmt.Errorf("ini: %w", err)
+			}
+		}
+	}
+
+	return decodeMap(m, out)
+}
+
+// Name unmarshal name
+func (i *INI) Name() string {
+	return "ini"
+}
+
+// Marshal marshal by ini
+func (i *INI) Marshal(in interface{}) ([]byte, error) {
+	m, err := toMap(in)
+	if err != nil {
+		return nil, err
+	}
+
+	leaves := map[string]string{}
+	flattenMap(m, "", ".", leaves)
+
+	f := ini.Empty()
+	for _, k := range sortedKeys(leaves) {
+		section, key := "", k
+		if idx := strings.LastIndex(k, "."); idx >= 0 {
+			section, key = k[:idx], k[idx+1:]
+		}
+
+		if _, err = f.Section(section).NewKey(key, leaves[k]); err != nil {
+			return nil, err
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	if _, err = f.WriteTo(buf); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
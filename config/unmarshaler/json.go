@@ -5,7 +5,7 @@ import (
 )
 
 func init() {
-	register(&JSON{})
+	Register(&JSON{})
 }
 
 // JSON json unmarshaler
@@ -13,7 +13,16 @@ type JSON struct{}
 
 // Unmarshal unmarshal by json
 func (j *JSON) Unmarshal(in []byte, out interface{}) error {
-	in = []byte(expandEnv(string(in)))
+	expanded, err := expandEnv(string(in))
+	if err != nil {
+		return err
+	}
+
+	return j.Decode([]byte(expanded), out)
+}
+
+// Decode unmarshal by json without expanding environment variables
+func (j *JSON) Decode(in []byte, out interface{}) error {
 	return json.Unmarshal(in, out)
 }
 
@@ -21,3 +30,8 @@ func (j *JSON) Unmarshal(in []byte, out interface{}) error {
 func (j *JSON) Name() string {
 	return "json"
 }
+
+// Marshal marshal by json
+func (j *JSON) Marshal(in interface{}) ([]byte, error) {
+	return json.Marshal(in)
+}
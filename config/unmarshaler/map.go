@@ -0,0 +1,173 @@
+package unmarshaler
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// decodeMap decode nested map into out
+//
+// out other than *map[string]interface{} and *interface{} is decoded by yaml,
+// so struct fields are matched by yaml tags.
+func decodeMap(m map[string]interface{}, out interface{}) error {
+	switch v := out.(type) {
+	case *map[string]interface{}:
+		if *v == nil {
+			*v = map[string]interface{}{}
+		}
+		for k, val := range m {
+			(*v)[k] = val
+		}
+		return nil
+	case *interface{}:
+		*v = m
+		return nil
+	}
+
+	data, err := yaml.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	return yaml.Unmarshal(data, out)
+}
+
+// toMap convert in into nested map, in other than map is converted by yaml
+func toMap(in interface{}) (map[string]interface{}, error) {
+	if m, ok := toStringMap(in); ok {
+		return m, nil
+	}
+
+	data, err := yaml.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+
+	m := map[string]interface{}{}
+	if err = yaml.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// setNested set value into nested map by subkeys, missing sub maps will be created
+//
+// return error when a key holds both a value and sub keys, e.g. a=1 and a.b=2.
+func setNested(m map[string]interface{}, subkeys []string, val interface{}) error {
+	for i, k := range subkeys[:len(subkeys)-1] {
+		v, exist := m[k]
+		if !exist {
+			v = map[string]interface{}{}
+			m[k] = v
+		}
+
+		sub, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("key %s has both value and sub keys", strings.Join(subkeys[:i+1], "."))
+		}
+		m = sub
+	}
+
+	k := subkeys[len(subkeys)-1]
+	if _, ok := m[k].(map[string]interface{}); ok {
+		return fmt.Errorf("key %s has both value and sub keys", strings.Join(subkeys, "."))
+	}
+	m[k] = val
+
+	return nil
+}
+
+// splitKey split key into subkeys by sep, empty subkeys are dropped
+func splitKey(k, sep string) []string {
+	var subkeys []string
+	for _, subkey := range strings.Split(k, sep) {
+		if subkey = strings.TrimSpace(subkey); subkey != "" {
+			subkeys = append(subkeys, subkey)
+		}
+	}
+
+	return subkeys
+}
+
+// parseScalar parse string value into bool, int or float
+//
+// the value is kept as string when it can not round trip, e.g. 0123, 1.50.
+func parseScalar(s string) interface{} {
+	if b, err := strconv.ParseBool(s); err == nil && strconv.FormatBool(b) == s {
+		return b
+	}
+
+	if i, err := strconv.Atoi(s); err == nil && strconv.Itoa(i) == s {
+		return i
+	}
+
+	if f, err := strconv.ParseFloat(s, 64); err == nil && strconv.FormatFloat(f, 'f', -1, 64) == s {
+		return f
+	}
+
+	return s
+}
+
+// flattenMap flatten nested map into leaves joined by sep
+//
+// slices are joined by comma.
+func flattenMap(m map[string]interface{}, prefix, sep string, leaves map[string]string) {
+	for k, v := range m {
+		key := k
+		if prefix != "" {
+			key = prefix + sep + k
+		}
+
+		if sub, ok := toStringMap(v); ok {
+			flattenMap(sub, key, sep, leaves)
+			continue
+		}
+
+		leaves[key] = formatValue(v)
+	}
+}
+
+func formatValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case []interface{}:
+		items := make([]string, 0, len(val))
+		for _, item := range val {
+			items = append(items, formatValue(item))
+		}
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+func toStringMap(v interface{}) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m, true
+	case map[interface{}]interface{}:
+		ret := make(map[string]interface{}, len(m))
+		for k, val := range m {
+			ret[fmt.Sprint(k)] = val
+		}
+		return ret, true
+	default:
+		return nil, false
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,86 @@
+package unmarshaler
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/magiconair/properties"
+)
+
+func init() {
+	Register(&Properties{})
+}
+
+// Properties java properties unmarshaler
+//
+// key is split by dot, e.g. client.redis.max_idle.
+type Properties struct{}
+
+// Unmarshal unmarshal by java properties
+func (p *Properties) Unmarshal(in []byte, out interface{}) error {
+	expanded, err := expandEnv(string(in))
+	if err != nil {
+		return err
+	}
+
+	return p.Decode([]byte(expanded), out)
+}
+
+// Decode unmarshal by java properties without expanding environment variables
+func (p *Properties) Decode(in []byte, out interface{}) error {
+	loader := &properties.Loader{
+		Encoding:         properties.UTF8,
+		DisableExpansion: true,
+	}
+
+	props, err := loader.LoadBytes(in)
+	if err != nil {
+		return err
+	}
+
+	m := map[string]interface{}{}
+	for _, k := range props.Keys() {
+		subkeys := splitKey(k, ".")
+		if len(subkeys) == 0 {
+			continue
+		}
+
+		v, _ := props.Get(k)
+		if err = setNested(m, subkeys, parseScalar(v)); err != nil {
+			return fmt.Errorf("properties: %w", err)
+		}
+	}
+
+	return decodeMap(m, out)
+}
+
+// Name unmarshal name
+func (p *Properties) Name() string {
+	return "properties"
+}
+
+// Marshal marshal by java properties
+func (p *Properties) Marshal(in interface{}) ([]byte, error) {
+	m, err := toMap(in)
+	if err != nil {
+		return nil, err
+	}
+
+	leaves := map[string]string{}
+	flattenMap(m, "", ".", leaves)
+
+	props := properties.NewProperties()
+	props.DisableExpansion = true
+	for _, k := range sortedKeys(leaves) {
+		if _, _, err = props.Set(k, leaves[k]); err != nil {
+			return nil, err
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	if _, err = props.Write(buf, properties.UTF8); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
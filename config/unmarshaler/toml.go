@@ -1,11 +1,13 @@
 package unmarshaler
 
 import (
+	"bytes"
+
 	"github.com/BurntSushi/toml"
 )
 
 func init() {
-	register(&TOML{})
+	Register(&TOML{})
 }
 
 // TOML toml unmarshaler
@@ -13,7 +15,16 @@ type TOML struct{}
 
 // Unmarshal unmarshal by toml
 func (t *TOML) Unmarshal(in []byte, out interface{}) error {
-	in = []byte(expandEnv(string(in)))
+	expanded, err := expandEnv(string(in))
+	if err != nil {
+		return err
+	}
+
+	return t.Decode([]byte(expanded), out)
+}
+
+// Decode unmarshal by toml without expanding environment variables
+func (t *TOML) Decode(in []byte, out interface{}) error {
 	return toml.Unmarshal(in, out)
 }
 
@@ -21,3 +32,13 @@ func (t *TOML) Unmarshal(in []byte, out interface{}) error {
 func (t *TOML) Name() string {
 	return "toml"
 }
+
+// Marshal marshal by toml
+func (t *TOML) Marshal(in interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := toml.NewEncoder(buf).Encode(in); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -1,20 +1,36 @@
 package unmarshaler
 
 import (
+	"fmt"
+	"io/ioutil"
 	"os"
+	"strings"
 	"sync"
 )
 
 var (
 	unmarshalerMap   = map[string]Unmarshaler{}
+	extensionMap     = map[string]Unmarshaler{}
 	unmarshalerMapRW sync.RWMutex
 )
 
-// register register unmarshaler
-func register(unmarshaler Unmarshaler) {
+// Register register unmarshaler by name and file extensions
+//
+// extensions are used to detect the unmarshaler by file name, e.g. ".yml",
+// default "." + name when no extension assigned.
+// the unmarshaler with the same name or extension will be replaced.
+func Register(unmarshaler Unmarshaler, exts ...string) {
 	unmarshalerMapRW.Lock()
 	defer unmarshalerMapRW.Unlock()
+
+	if len(exts) == 0 {
+		exts = []string{"." + unmarshaler.Name()}
+	}
+
 	unmarshalerMap[unmarshaler.Name()] = unmarshaler
+	for _, ext := range exts {
+		extensionMap[strings.ToLower(ext)] = unmarshaler
+	}
 }
 
 // Get get unmarshaler by name
@@ -24,6 +40,13 @@ func Get(name string) Unmarshaler {
 	return unmarshalerMap[name]
 }
 
+// GetByExtension get unmarshaler by file extension, e.g. ".json"
+func GetByExtension(ext string) Unmarshaler {
+	unmarshalerMapRW.RLock()
+	defer unmarshalerMapRW.RUnlock()
+	return extensionMap[strings.ToLower(ext)]
+}
+
 // Unmarshaler ...
 type Unmarshaler interface {
 
@@ -34,50 +57,127 @@ type Unmarshaler interface {
 	Name() string
 }
 
+// Marshaler marshal data by the format of the unmarshaler
+type Marshaler interface {
+
+	// Marshal ...
+	Marshal(interface{}) ([]byte, error)
+
+	// Decode unmarshal data marshaled by Marshal without expanding environment variables
+	Decode([]byte, interface{}) error
+}
+
 // expandEnv 寻找 ${var} 并替换为环境变量的值，没有则替换为空，不解析 $var
 //
 // os.ExpandEnv 会同时处理${var}和$var，配置文件中可能包含一些含特殊字符$的配置项，
 // 如redisClient、mysqlClient的连接密码。
-func expandEnv(s string) string {
+//
+// 支持以下形式:
+//
+//	${VAR:-default}  环境变量未设置或为空时使用默认值
+//	${VAR:?message}  环境变量未设置或为空时返回错误
+//	${file:/path}    替换为文件内容，去掉末尾换行，如 docker/kubernetes secret 文件
+//	${a.b.c}         引用配置中的其他key，保留原样，由 config 在合并后解析
+func expandEnv(s string) (string, error) {
 	var buf []byte
 	i := 0
 	for j := 0; j < len(s); j++ {
 		if s[j] == '$' && j+2 < len(s) && s[j+1] == '{' {
+			expr, w := getEnvExpr(s[j+1:])
+			if w == 0 { // 没有匹配中，保留$
+				continue
+			}
+
 			if buf == nil {
 				buf = make([]byte, 0, 2*len(s))
 			}
 			buf = append(buf, s[i:j]...)
-			name, w := getEnvName(s[j+1:])
-			if name == "" && w > 0 {
-			} else if name == "" {
-				buf = append(buf, s[j])
+
+			val, keep, err := expandExpr(expr)
+			if err != nil {
+				return "", err
+			}
+
+			if keep {
+				buf = append(buf, s[j:j+1+w]...)
 			} else {
-				buf = append(buf, os.Getenv(name)...)
+				buf = append(buf, val...)
 			}
+
 			j += w
 			i = j + 1
 		}
 	}
 	if buf == nil {
-		return s
+		return s, nil
 	}
-	return string(buf) + s[i:]
+	return string(buf) + s[i:], nil
 }
 
-// getEnvName 获取环境变量名，即${var}里面的var内容，返回var内容及其长度
-func getEnvName(s string) (string, int) {
+// getEnvExpr 获取${}里面的内容，返回内容及包含括号的长度，没有匹配中返回长度0
+func getEnvExpr(s string) (string, int) {
 	// 匹配右括号 }
 	// 输入已经保证第一个字符是{，并且至少两个字符以上
+	inName := true
 	for i := 1; i < len(s); i++ {
-		if s[i] == ' ' || s[i] == '\n' || s[i] == '"' { // "xx${xxx"
+		if s[i] == '\n' {
+			return "", 0
+		}
+		if inName && (s[i] == ' ' || s[i] == '"') { // "xx${xxx"
 			return "", 0 // 遇到上面这些字符认为没有匹配中，保留$
 		}
+		if s[i] == ':' {
+			inName = false
+		}
 		if s[i] == '}' {
-			if i == 1 { // ${}
-				return "", 2 // 去掉${}
-			}
 			return s[1:i], i + 1
 		}
 	}
 	return "", 0 // 没有右括号，保留$
 }
+
+// expandExpr 展开${}里面的表达式，keep 为 true 时保留原样
+func expandExpr(expr string) (val string, keep bool, err error) {
+	if expr == "" { // ${}
+		return "", false, nil
+	}
+
+	if strings.HasPrefix(expr, "file:") {
+		path := expr[len("file:"):]
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", false, fmt.Errorf("read file fail. file:%s err:%w", path, err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), false, nil
+	}
+
+	name, op, arg := expr, "", ""
+	if idx := strings.Index(expr, ":"); idx >= 0 {
+		name, op = expr[:idx], expr[idx+1:]
+		if op != "" {
+			op, arg = op[:1], op[1:]
+		}
+	}
+
+	// 引用配置中的其他key
+	if strings.Contains(name, ".") {
+		return "", true, nil
+	}
+
+	val = os.Getenv(name)
+	if val != "" {
+		return val, false, nil
+	}
+
+	switch op {
+	case "-":
+		return arg, false, nil
+	case "?":
+		if arg == "" {
+			arg = "not set"
+		}
+		return "", false, fmt.Errorf("environment variable %s: %s", name, arg)
+	}
+
+	return val, false, nil
+}
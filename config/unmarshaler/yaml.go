@@ -5,7 +5,7 @@ import (
 )
 
 func init() {
-	register(&YAML{})
+	Register(&YAML{}, ".yaml", ".yml")
 }
 
 // YAML yaml unmarshaler
@@ -13,7 +13,16 @@ type YAML struct{}
 
 // Unmarshal unmarshal by yaml
 func (y *YAML) Unmarshal(in []byte, out interface{}) error {
-	in = []byte(expandEnv(string(in)))
+	expanded, err := expandEnv(string(in))
+	if err != nil {
+		return err
+	}
+
+	return y.Decode([]byte(expanded), out)
+}
+
+// Decode unmarshal by yaml without expanding environment variables
+func (y *YAML) Decode(in []byte, out interface{}) error {
 	return yaml.Unmarshal(in, out)
 }
 
@@ -21,3 +30,8 @@ func (y *YAML) Unmarshal(in []byte, out interface{}) error {
 func (y *YAML) Name() string {
 	return "yaml"
 }
+
+// Marshal marshal by yaml
+func (y *YAML) Marshal(in interface{}) ([]byte, error) {
+	return yaml.Marshal(in)
+}
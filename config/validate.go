@@ -0,0 +1,328 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	tagDefault  = "default"
+	tagValidate = "validate"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// FieldError invalid field
+type FieldError struct {
+	// Key dotted key of the field, e.g. client.redis.max_idle
+	Key string
+
+	// Rule the rule which the field violates, e.g. required, min=1
+	Rule string
+
+	// Value value of the field
+	Value interface{}
+}
+
+// Error ...
+func (f *FieldError) Error() string {
+	return fmt.Sprintf("%s: violate %s, value:%v", f.Key, f.Rule, f.Value)
+}
+
+// ValidationError error of all invalid fields
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+// Error ...
+func (v *ValidationError) Error() string {
+	errs := make([]string, 0, len(v.Errors))
+	for _, err := range v.Errors {
+		errs = append(errs, err.Error())
+	}
+
+	return fmt.Sprintf("%s: validate fail. %s", packageName, strings.Join(errs, "; "))
+}
+
+// structWalker fill default value and validate struct fields by tags
+//
+// default tag value is parsed as yaml, e.g. default:"500ms", default:"[a, b]".
+// default value is filled only when the field is zero value and the key is absent in the data decoded,
+// so the values set explicitly are kept, e.g. enabled: false, port: 0.
+// validate tag rules are separated by comma:
+//   - required: value must not be zero value
+//   - omitempty: skip other rules when value is zero value
+//   - min=n, max=n: number must in range, length of string, slice and map must in range
+//   - oneof=a b c: value must be one of the space separated values
+//   - regex=^\w+$: string must match the regular expression, must be the last rule
+type structWalker struct {
+	// nameTag tag used to name the field in dotted key, e.g. yaml
+	nameTag string
+	errs    []*FieldError
+
+	// defaults dotted keys of the fields filled by default tag => default values
+	defaults map[string]interface{}
+}
+
+// fillAndValidate fill default value and validate fields of out
+//
+// data is the data out decoded from, nil when unknown. prefix is the dotted key of out,
+// return *ValidationError when any field is invalid.
+func fillAndValidate(out, data interface{}, prefix, nameTag string) error {
+	return (&structWalker{nameTag: nameTag}).fillAndValidate(out, data, prefix)
+}
+
+func (w *structWalker) fillAndValidate(out, data interface{}, prefix string) error {
+	w.walk(reflect.ValueOf(out), prefix, data)
+	if len(w.errs) == 0 {
+		return nil
+	}
+
+	return &ValidationError{Errors: w.errs}
+}
+
+// walk walk v decoded from data, data is nil when the key is absent or unknown
+func (w *structWalker) walk(v reflect.Value, key string, data interface{}) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			w.walk(v.Elem(), key, data)
+		}
+	case reflect.Struct:
+		w.walkStruct(v, key, data)
+	case reflect.Slice, reflect.Array:
+		items, _ := data.([]interface{})
+		for i := 0; i < v.Len(); i++ {
+			var item interface{}
+			if i < len(items) {
+				item = items[i]
+			}
+			w.walk(v.Index(i), joinKey(key, strconv.Itoa(i)), item)
+		}
+	case reflect.Map:
+		if !hasStruct(v.Type().Elem()) {
+			return
+		}
+
+		for _, k := range v.MapKeys() {
+			name := fmt.Sprint(k.Interface())
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(k))
+			val, _ := lookupField(data, name)
+			w.walk(elem, joinKey(key, name), val)
+			v.SetMapIndex(k, elem)
+		}
+	}
+}
+
+func (w *structWalker) walkStruct(v reflect.Value, key string, data interface{}) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		name, inline := w.fieldName(field)
+		if name == "-" {
+			continue
+		}
+
+		fieldKey, fieldData, exist := key, data, false
+		if !inline {
+			fieldKey = joinKey(key, name)
+			fieldData, exist = lookupField(data, name)
+		}
+
+		fieldVal := v.Field(i)
+		if !fieldVal.CanSet() {
+			w.walk(fieldVal, fieldKey, fieldData)
+			continue
+		}
+
+		if def, ok := field.Tag.Lookup(tagDefault); ok && fieldVal.IsZero() && !exist {
+			if err := yaml.Unmarshal([]byte(def), fieldVal.Addr().Interface()); err != nil {
+				w.addError(fieldKey, fmt.Sprintf("%s=%s", tagDefault, def), def)
+			} else {
+				w.addDefault(fieldKey, fieldVal.Interface())
+			}
+		}
+
+		if rules, ok := field.Tag.Lookup(tagValidate); ok {
+			w.validate(fieldVal, fieldKey, rules)
+		}
+
+		w.walk(fieldVal, fieldKey, fieldData)
+	}
+}
+
+// lookupField lookup the value of the field name in data ignoring case, underscores and hyphens,
+// the exact key takes precedence
+func lookupField(data interface{}, name string) (interface{}, bool) {
+	m, ok := toStringMap(data)
+	if !ok {
+		return nil, false
+	}
+
+	key, exist := matchKeyFold(m, name)
+	return m[key], exist
+}
+
+func (w *structWalker) addDefault(key string, val interface{}) {
+	if w.defaults == nil {
+		w.defaults = map[string]interface{}{}
+	}
+
+	w.defaults[key] = val
+}
+
+// fieldName name of field in dotted key, inline is true when the field is embedded without name
+func (w *structWalker) fieldName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get(w.nameTag)
+	name := strings.Split(tag, ",")[0]
+	if name != "" {
+		return name, false
+	}
+
+	if field.Anonymous || strings.Contains(tag, "inline") {
+		return "", true
+	}
+
+	return strings.ToLower(field.Name), false
+}
+
+func (w *structWalker) validate(v reflect.Value, key, rules string) {
+	if strings.Contains(","+rules, ",omitempty") && v.IsZero() {
+		return
+	}
+
+	for rules != "" {
+		rule := rules
+		if strings.HasPrefix(rules, "regex=") {
+			rules = ""
+		} else if i := strings.Index(rules, ","); i >= 0 {
+			rule, rules = rules[:i], rules[i+1:]
+		} else {
+			rules = ""
+		}
+
+		if !checkRule(v, rule) {
+			w.addError(key, rule, v.Interface())
+		}
+	}
+}
+
+func (w *structWalker) addError(key, rule string, val interface{}) {
+	w.errs = append(w.errs, &FieldError{
+		Key:   key,
+		Rule:  rule,
+		Value: val,
+	})
+}
+
+// checkRule report whether v satisfy the rule, invalid rule is treated as violated
+func checkRule(v reflect.Value, rule string) bool {
+	kv := strings.SplitN(rule, "=", 2)
+	param := ""
+	if len(kv) == 2 {
+		param = kv[1]
+	}
+
+	switch kv[0] {
+	case "", "omitempty":
+		return true
+	case "required":
+		return !v.IsZero()
+	case "min":
+		n, limit, ok := compareValues(v, param)
+		return ok && n >= limit
+	case "max":
+		n, limit, ok := compareValues(v, param)
+		return ok && n <= limit
+	case "oneof":
+		val := fmt.Sprint(v.Interface())
+		for _, option := range strings.Fields(param) {
+			if val == option {
+				return true
+			}
+		}
+		return false
+	case "regex":
+		re, err := regexp.Compile(param)
+		return err == nil && v.Kind() == reflect.String && re.MatchString(v.String())
+	default:
+		return false
+	}
+}
+
+// compareValues return the comparable number of v and limit
+//
+// length is used for string, slice and map, durations support limit like 1s.
+func compareValues(v reflect.Value, limit string) (float64, float64, bool) {
+	if v.Type() == durationType {
+		d, err := cast.ToDurationE(limit)
+		return float64(v.Int()), float64(d), err == nil
+	}
+
+	l, err := strconv.ParseFloat(limit, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), l, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), l, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), l, true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), l, true
+	default:
+		return 0, 0, false
+	}
+}
+
+// hasStruct report whether t is struct or pointer to struct
+func hasStruct(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t.Kind() == reflect.Struct
+}
+
+// fillAndValidate fill default value and validate fields of out like fillAndValidate,
+// and record the defaults filled to be dumped.
+func (c *configureImpl) fillAndValidate(out, data interface{}, prefix string) error {
+	w := &structWalker{nameTag: c.unmarshaler.Name()}
+	err := w.fillAndValidate(out, data, prefix)
+
+	if len(w.defaults) > 0 {
+		c.defaultsMu.Lock()
+		if c.defaults == nil {
+			c.defaults = map[string]interface{}{}
+		}
+		for k, v := range w.defaults {
+			c.defaults[k] = v
+		}
+		c.defaultsMu.Unlock()
+	}
+
+	return c.redact(err)
+}
+
+func joinKey(prefix, k string) string {
+	if prefix == "" {
+		return k
+	}
+
+	return prefix + "." + k
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_fillAndValidate(t *testing.T) {
+	type redisConfig struct {
+		MaxIdle int           `yaml:"max_idle" default:"2048" validate:"min=1,max=4096"`
+		Timeout time.Duration `yaml:"timeout" default:"1s" validate:"min=10ms"`
+	}
+
+	type serviceConfig struct {
+		Name string `yaml:"name" validate:"required,regex=^[a-z,]+$"`
+		DSN  string `yaml:"dsn" validate:"required"`
+		Mode string `yaml:"mode" validate:"omitempty,oneof=single cluster"`
+
+		redisConfig `yaml:",inline"`
+	}
+
+	type appConfig struct {
+		Client struct {
+			Hosts   []string        `yaml:"hosts" default:"[127.0.0.1]"`
+			Service []serviceConfig `yaml:"service"`
+		} `yaml:"client"`
+		Services map[string]*serviceConfig `yaml:"services"`
+	}
+
+	tests := []struct {
+		name     string
+		out      *appConfig
+		want     *appConfig
+		wantErrs []*FieldError
+	}{
+		{
+			name: "fill default value",
+			out: func() *appConfig {
+				c := &appConfig{}
+				c.Client.Service = []serviceConfig{{Name: "cache", DSN: "dsn"}}
+				return c
+			}(),
+			want: func() *appConfig {
+				c := &appConfig{}
+				c.Client.Hosts = []string{"127.0.0.1"}
+				c.Client.Service = []serviceConfig{{
+					Name:        "cache",
+					DSN:         "dsn",
+					redisConfig: redisConfig{MaxIdle: 2048, Timeout: time.Second},
+				}}
+				return c
+			}(),
+		},
+		{
+			name: "invalid fields",
+			out: func() *appConfig {
+				c := &appConfig{}
+				c.Client.Service = []serviceConfig{{
+					Name:        "Cache",
+					Mode:        "sentinel",
+					redisConfig: redisConfig{MaxIdle: 8192, Timeout: time.Millisecond},
+				}}
+				c.Services = map[string]*serviceConfig{"cache": {Name: "cache"}}
+				return c
+			}(),
+			wantErrs: []*FieldError{
+				{Key: "client.service.0.name", Rule: "regex=^[a-z,]+$", Value: "Cache"},
+				{Key: "client.service.0.dsn", Rule: "required", Value: ""},
+				{Key: "client.service.0.mode", Rule: "oneof=single cluster", Value: "sentinel"},
+				{Key: "client.service.0.max_idle", Rule: "max=4096", Value: 8192},
+				{Key: "client.service.0.timeout", Rule: "min=10ms", Value: time.Millisecond},
+				{Key: "services.cache.dsn", Rule: "required", Value: ""},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := fillAndValidate(tt.out, nil, "", "yaml")
+			if tt.wantErrs == nil {
+				if err != nil {
+					t.Errorf("fillAndValidate() error = %v", err)
+					return
+				}
+				if !reflect.DeepEqual(tt.out, tt.want) {
+					t.Errorf("fillAndValidate() = %+v, want %+v", tt.out, tt.want)
+				}
+				return
+			}
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Errorf("fillAndValidate() error = %v, want *ValidationError", err)
+				return
+			}
+			if !reflect.DeepEqual(validationErr.Errors, tt.wantErrs) {
+				t.Errorf("fillAndValidate() error = %v, want %v", validationErr.Errors, tt.wantErrs)
+			}
+		})
+	}
+}
+
+func Test_configureImpl_UnmarshalKey_validate(t *testing.T) {
+	c := defaultConfigure("./testdata/config.yaml")
+	defer c.Close()
+	_ = c.Load()
+
+	out := struct {
+		StringValue  string `yaml:"string_value" validate:"oneof=a b"`
+		DefaultValue string `yaml:"default_value" default:"default value"`
+	}{}
+
+	err := c.UnmarshalKey("subkey", &out)
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.Errors) != 1 {
+		t.Errorf("configureImpl.UnmarshalKey() error = %v, want *ValidationError", err)
+		return
+	}
+	if got := validationErr.Errors[0].Key; got != "subkey.string_value" {
+		t.Errorf("FieldError.Key = %v, want %v", got, "subkey.string_value")
+	}
+	if out.DefaultValue != "default value" {
+		t.Errorf("configureImpl.UnmarshalKey() = %+v", out)
+	}
+}
+
+func Test_configureImpl_Unmarshal_explicitZero(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	writeFile(t, path, "server:\n  enabled: false\n  port: 0\n  service:\n    - port: 0\n    - name: b\n")
+
+	c := defaultConfigure(path)
+	withTest()(c)
+	if err := c.Load(); err != nil {
+		t.Fatalf("configureImpl.Load() error = %v", err)
+	}
+	defer c.Close()
+
+	type service struct {
+		Name string `yaml:"name"`
+		Port int    `yaml:"port" default:"6379"`
+	}
+
+	type server struct {
+		Enabled bool      `yaml:"enabled" default:"true"`
+		Port    int       `yaml:"port" default:"8080"`
+		Host    string    `yaml:"host" default:"127.0.0.1"`
+		Service []service `yaml:"service"`
+	}
+
+	want := server{Host: "127.0.0.1", Service: []service{{Port: 0}, {Name: "b", Port: 6379}}}
+
+	var out server
+	if err := c.UnmarshalKey("server", &out); err != nil || !reflect.DeepEqual(out, want) {
+		t.Errorf("configureImpl.UnmarshalKey() = %+v, error = %v, want %+v", out, err, want)
+	}
+
+	var all struct {
+		Server server `yaml:"server"`
+	}
+	if err := c.Unmarshal(&all); err != nil || !reflect.DeepEqual(all.Server, want) {
+		t.Errorf("configureImpl.Unmarshal() = %+v, error = %v, want %+v", all.Server, err, want)
+	}
+}
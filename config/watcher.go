@@ -0,0 +1,246 @@
+package config
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// defaultWatchDebounce bursts of file events within the duration trigger a single reload
+	defaultWatchDebounce = 100 * time.Millisecond
+
+	// defaultPollInterval interval of polling when fsnotify is not available
+	defaultPollInterval = 5 * time.Second
+)
+
+// watch watch files and providers of the config and reload when any of them changed
+//
+// parent directories are watched instead of files, so atomic rename (write then rename)
+// and Kubernetes ConfigMap symlink swap (..data directory flip) are handled.
+// files are polled instead when poll interval is set.
+func (c *configureImpl) watch(callback func(*configureImpl)) {
+	watchFiles := len(c.watchFiles()) > 0 && (c.pollInterval > 0 || c.watcher != nil)
+	if !watchFiles && len(c.providers) == 0 {
+		return
+	}
+
+	c.done = make(chan struct{})
+	for _, p := range c.providers {
+		go c.watchProvider(p, callback)
+	}
+
+	if !watchFiles {
+		return
+	}
+
+	if c.pollInterval > 0 {
+		go c.pollLoop(callback)
+		return
+	}
+
+	files := newWatchedFiles(c.watchFiles())
+	c.watchDirs(files)
+
+	go c.watchLoop(files, callback)
+}
+
+func (c *configureImpl) watchLoop(files *watchedFiles, callback func(*configureImpl)) {
+	defer logInfo("%s: break file watch. file:%s\n", packageName, c.path)
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-c.done:
+			return
+		case event, ok := <-c.watcher.Events:
+			if !ok {
+				return
+			}
+
+			if event.Op == fsnotify.Chmod || !files.match(event.Name) {
+				continue
+			}
+
+			// the real path may move to a new directory after symlink swap
+			c.watchDirs(files)
+
+			debounce = time.After(c.watchDebounce)
+		case err, ok := <-c.watcher.Errors:
+			if !ok {
+				return
+			}
+
+			logErrorf("%s: file watch error. file:%s err:%v\n", packageName, c.path, err)
+		case <-debounce:
+			debounce = nil
+			c.onChanged(callback)
+
+			// included files may changed after reload
+			files = newWatchedFiles(c.watchFiles())
+			c.watchDirs(files)
+		}
+	}
+}
+
+// watchDirs add the parent directories of files to the watcher
+func (c *configureImpl) watchDirs(files *watchedFiles) {
+	for _, dir := range files.dirs() {
+		if err := c.watcher.Add(dir); err != nil {
+			logErrorf("%s: watch dir fail. dir:%s err:%v\n", packageName, dir, err)
+		}
+	}
+}
+
+// pollLoop poll the content of files every interval, reload when the fingerprint changed
+func (c *configureImpl) pollLoop(callback func(*configureImpl)) {
+	files := c.watchFiles()
+	fingerprints := fileFingerprints(files)
+
+	ticker := time.NewTicker(c.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
+
+		newFingerprints := fileFingerprints(files)
+		if reflect.DeepEqual(fingerprints, newFingerprints) {
+			continue
+		}
+
+		c.onChanged(callback)
+
+		// included files may changed after reload
+		files = c.watchFiles()
+		fingerprints = fileFingerprints(files)
+	}
+}
+
+// onChanged reload and call back, callbacks are skipped when reload fail
+func (c *configureImpl) onChanged(callback func(*configureImpl)) {
+	if err := c.Reload(); err != nil {
+		return
+	}
+
+	if callback != nil {
+		callback(c)
+	}
+
+	if c.watchCallback != nil {
+		go c.watchCallback(c)
+	}
+}
+
+// watchFiles local files of the config, including the included files of the last read
+func (c *configureImpl) watchFiles() []string {
+	var files []string
+	for _, s := range c.sources() {
+		if f, ok := s.(*fileSource); ok {
+			files = append(files, f.path)
+		}
+	}
+
+	c.rw.RLock()
+	defer c.rw.RUnlock()
+
+	return append(files, c.includes...)
+}
+
+// watchedFiles files and their symlink resolved real paths
+type watchedFiles struct {
+	// realPaths absolute path => real path
+	realPaths map[string]string
+}
+
+func newWatchedFiles(paths []string) *watchedFiles {
+	w := &watchedFiles{
+		realPaths: map[string]string{},
+	}
+
+	for _, path := range paths {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+
+		w.realPaths[path] = resolvePath(path)
+	}
+
+	return w
+}
+
+// dirs parent directories of files and real paths
+func (w *watchedFiles) dirs() []string {
+	exist := map[string]bool{}
+	var dirs []string
+	for path, realPath := range w.realPaths {
+		for _, dir := range []string{filepath.Dir(path), filepath.Dir(realPath)} {
+			if !exist[dir] {
+				exist[dir] = true
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+
+	return dirs
+}
+
+// match report whether the event of name affects any file
+//
+// the event of file itself or its real path matches, the symlink changes
+// (e.g. ..data directory flipped) are detected by resolving the real paths again.
+func (w *watchedFiles) match(name string) bool {
+	name = filepath.Clean(name)
+
+	matched := false
+	for path, realPath := range w.realPaths {
+		if name == path || name == realPath {
+			matched = true
+		}
+
+		if newRealPath := resolvePath(path); newRealPath != realPath {
+			w.realPaths[path] = newRealPath
+			matched = true
+		}
+	}
+
+	return matched
+}
+
+// fileFingerprints sha256 of the content of files, empty when the file can not be read
+func fileFingerprints(files []string) []string {
+	fingerprints := make([]string, 0, len(files))
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			fingerprints = append(fingerprints, "")
+			continue
+		}
+
+		fingerprints = append(fingerprints, fmt.Sprintf("%x", sha256.Sum256(data)))
+	}
+
+	return fingerprints
+}
+
+// resolvePath resolve symlinks of path, return path itself when fail, e.g. file removed
+func resolvePath(path string) string {
+	realPath, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return path
+	}
+
+	if abs, err := filepath.Abs(realPath); err == nil {
+		return abs
+	}
+
+	return realPath
+}
@@ -0,0 +1,232 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_configureImpl_watch(t *testing.T) {
+	tests := []struct {
+		name   string
+		setup  func(dir string) string
+		update func(t *testing.T, dir string)
+	}{
+		{
+			name: "write",
+			setup: func(dir string) string {
+				path := filepath.Join(dir, "config.yaml")
+				writeFile(t, path, "value: 1\n")
+				return path
+			},
+			update: func(t *testing.T, dir string) {
+				writeFile(t, filepath.Join(dir, "config.yaml"), "value: 2\n")
+			},
+		},
+		{
+			name: "atomic rename",
+			setup: func(dir string) string {
+				path := filepath.Join(dir, "config.yaml")
+				writeFile(t, path, "value: 1\n")
+				return path
+			},
+			update: func(t *testing.T, dir string) {
+				writeFile(t, filepath.Join(dir, "config.yaml.tmp"), "value: 2\n")
+				if err := os.Rename(filepath.Join(dir, "config.yaml.tmp"), filepath.Join(dir, "config.yaml")); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "configmap symlink swap",
+			setup: func(dir string) string {
+				writeFile(t, filepath.Join(dir, "..2022_01", "config.yaml"), "value: 1\n")
+				symlink(t, "..2022_01", filepath.Join(dir, "..data"))
+				symlink(t, filepath.Join("..data", "config.yaml"), filepath.Join(dir, "config.yaml"))
+				return filepath.Join(dir, "config.yaml")
+			},
+			update: func(t *testing.T, dir string) {
+				writeFile(t, filepath.Join(dir, "..2022_02", "config.yaml"), "value: 2\n")
+				symlink(t, "..2022_02", filepath.Join(dir, "..data_tmp"))
+				if err := os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.RemoveAll(filepath.Join(dir, "..2022_01")); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "included file",
+			setup: func(dir string) string {
+				writeFile(t, filepath.Join(dir, "common", "value.yaml"), "value: 1\n")
+				writeFile(t, filepath.Join(dir, "config.yaml"), "include: common/value.yaml\n")
+				return filepath.Join(dir, "config.yaml")
+			},
+			update: func(t *testing.T, dir string) {
+				writeFile(t, filepath.Join(dir, "common", "value.yaml"), "value: 2\n")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			c := defaultConfigure(tt.setup(dir))
+			defer c.Close()
+			if c.watcher == nil {
+				t.Skip("fsnotify not supported")
+			}
+			defer c.watcher.Close()
+
+			if err := c.Load(); err != nil {
+				t.Fatal(err)
+			}
+
+			var reloads int32
+			c.watch(func(*configureImpl) { atomic.AddInt32(&reloads, 1) })
+
+			tt.update(t, dir)
+			if !waitFor(func() bool { return c.GetInt("value", 0) == 2 }) {
+				t.Errorf("configureImpl.GetInt() = %v, want %v", c.GetInt("value", 0), 2)
+			}
+
+			// the watch keeps working after the file replaced
+			writeFile(t, filepath.Join(filepath.Dir(resolvePath(c.path)), "config.yaml"), "value: 3\n")
+			if !waitFor(func() bool { return c.GetInt("value", 0) == 3 }) {
+				t.Errorf("configureImpl.GetInt() = %v, want %v", c.GetInt("value", 0), 3)
+			}
+		})
+	}
+}
+
+func Test_configureImpl_watch_debounce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, path, "value: 0\n")
+
+	c := defaultConfigure(path)
+	defer c.Close()
+	if c.watcher == nil {
+		t.Skip("fsnotify not supported")
+	}
+	defer c.watcher.Close()
+
+	WithWatchDebounce(200 * time.Millisecond)(c)
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	var reloads int32
+	c.watch(func(*configureImpl) { atomic.AddInt32(&reloads, 1) })
+
+	for i := 1; i <= 5; i++ {
+		writeFile(t, path, "value: 5\n")
+	}
+
+	if !waitFor(func() bool { return atomic.LoadInt32(&reloads) > 0 }) {
+		t.Fatal("config not reloaded")
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	if got := atomic.LoadInt32(&reloads); got != 1 {
+		t.Errorf("reload count = %v, want %v", got, 1)
+	}
+}
+
+func Test_configureImpl_watch_reloadFail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, path, "value: 1\n")
+
+	var callbacks int32
+	c := defaultConfigure(path)
+	WithPollInterval(20 * time.Millisecond)(c)
+	WithWatchCallback(func(Configure) { atomic.AddInt32(&callbacks, 1) })(c)
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	c.watch(nil)
+	defer c.Close()
+
+	// replace atomically, the poller may read the truncated file otherwise
+	time.Sleep(100 * time.Millisecond)
+	writeFile(t, path+".tmp", "asdf")
+	if err := os.Rename(path+".tmp", path); err != nil {
+		t.Fatal(err)
+	}
+	if !waitFor(func() bool { return c.ReloadFailures() > 0 }) {
+		t.Fatal("config not reloaded")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if got := atomic.LoadInt32(&callbacks); got != 0 {
+		t.Errorf("watch callback count = %v, want %v", got, 0)
+	}
+
+	writeFile(t, path, "value: 2\n")
+	if !waitFor(func() bool { return atomic.LoadInt32(&callbacks) > 0 }) {
+		t.Errorf("watch callback not called after reload")
+	}
+}
+
+func writeFile(t *testing.T, path, data string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func symlink(t *testing.T, oldname, newname string) {
+	if err := os.Symlink(oldname, newname); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func waitFor(cond func() bool) bool {
+	for i := 0; i < 100; i++ {
+		if cond() {
+			return true
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	return false
+}
+
+func Test_configureImpl_poll(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, path, "value: 1\n")
+
+	c := defaultConfigure(path)
+	defer c.Close()
+	WithPollInterval(20 * time.Millisecond)(c)
+	if c.watcher != nil {
+		t.Errorf("configureImpl.watcher = %v, want nil", c.watcher)
+	}
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	var reloads int32
+	c.watch(func(*configureImpl) { atomic.AddInt32(&reloads, 1) })
+
+	time.Sleep(100 * time.Millisecond)
+	if got := atomic.LoadInt32(&reloads); got != 0 {
+		t.Errorf("reload count = %v, want %v", got, 0)
+	}
+
+	writeFile(t, path, "value: 2\n")
+	if !waitFor(func() bool { return c.GetInt("value", 0) == 2 }) {
+		t.Errorf("configureImpl.GetInt() = %v, want %v", c.GetInt("value", 0), 2)
+	}
+
+	// same size and modification time within the same second are detected by content
+	writeFile(t, path, "value: 3\n")
+	if !waitFor(func() bool { return c.GetInt("value", 0) == 3 }) {
+		t.Errorf("configureImpl.GetInt() = %v, want %v", c.GetInt("value", 0), 3)
+	}
+}
@@ -0,0 +1,239 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/wwwangxc/go-pkg/config/unmarshaler"
+)
+
+// Set set value of key at runtime, has the highest precedence
+//
+// values set are kept in a separate overlay and survive reloads,
+// unless WithDiscardSetOnReload. subscribers are notified of the changes.
+// the value is merged over the config read by the last load or reload without reading sources again,
+// the value is discarded when the merged config is invalid.
+// k support key1.key2.key3, element of slice can not be set
+func (c *configureImpl) Set(k string, val interface{}) error {
+	if k == "" {
+		return fmt.Errorf("%s: set fail, key is empty", packageName)
+	}
+
+	if c.unmarshaler == nil {
+		return ErrUnmarshalerNotExist
+	}
+
+	c.rw.RLock()
+	if key, exist := c.resolveKey(c.unmarshaledData, k); exist {
+		k = key
+	}
+	sliceElement := inSlice(c.unmarshaledData, k)
+	c.rw.RUnlock()
+
+	if sliceElement || strings.ContainsAny(k, "[]*") {
+		return fmt.Errorf("%s: set fail, set element of slice is not supported. key:%s", packageName, k)
+	}
+
+	c.updateMu.Lock()
+	sets := make(map[string]interface{}, len(c.sets)+1)
+	for key, v := range c.sets {
+		sets[key] = v
+	}
+
+	oldSets := c.sets
+	sets[k] = val
+	c.sets = sets
+
+	changes, err := c.publish(c.layers)
+	if err != nil {
+		c.sets = oldSets
+	}
+	c.updateMu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("%s: set fail. key:%s err:%w", packageName, k, err)
+	}
+
+	c.subscribers.notify(changes)
+	return nil
+}
+
+// inSlice report whether the dotted key is under slice
+func inSlice(m map[string]interface{}, k string) bool {
+	var val interface{} = m
+	for _, subkey := range strings.Split(k, ".") {
+		if _, ok := val.([]interface{}); ok {
+			return true
+		}
+
+		sub, ok := toStringMap(val)
+		if !ok {
+			return false
+		}
+		val = sub[subkey]
+	}
+
+	return false
+}
+
+// WriteTo write the effective config into file by the format
+//
+// format is the name of registered unmarshaler, e.g. yaml, toml,
+// the format is detected by the file extension when empty.
+// decrypted and interpolated values are written as they were, e.g. ENC(...), ${DB_PASSWORD}.
+// the file is replaced atomically.
+func (c *configureImpl) WriteTo(path, format string) error {
+	return c.writeKey("", path, format)
+}
+
+// writeKey write the sub tree of key into file by the format, the whole config when key is empty
+func (c *configureImpl) writeKey(k, path, format string) error {
+	u := unmarshaler.Get(format)
+	if format == "" {
+		u = detectUnmarshaler(path)
+	}
+
+	if u == nil {
+		return fmt.Errorf("%w. format:%s file:%s", ErrUnmarshalerNotExist, format, path)
+	}
+
+	m, ok := u.(unmarshaler.Marshaler)
+	if !ok {
+		return fmt.Errorf("%w. format:%s", ErrMarshalerNotExist, u.Name())
+	}
+
+	data, err := c.effectiveData(k)
+	if err != nil {
+		return err
+	}
+
+	raw, err := m.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("%s: marshal fail. format:%s err:%w", packageName, u.Name(), err)
+	}
+
+	if err = writeFileAtomic(path, raw); err != nil {
+		return fmt.Errorf("%s: write file fail. file:%s err:%w", packageName, path, err)
+	}
+
+	return nil
+}
+
+// effectiveData copy the sub tree of key with secret and interpolated values restored,
+// the whole config when key is empty
+func (c *configureImpl) effectiveData(k string) (map[string]interface{}, error) {
+	c.rw.RLock()
+	defer c.rw.RUnlock()
+
+	prefix := k
+	if key, exist := c.resolveKey(c.unmarshaledData, k); exist {
+		prefix = key
+	}
+
+	var data interface{} = c.unmarshaledData
+	for key, original := range c.interpolated {
+		if !hasKeyPrefix(key, prefix) && !hasKeyPrefix(prefix, key) {
+			continue
+		}
+
+		if _, ok := original.(notRestorable); ok {
+			return nil, fmt.Errorf("%s: interpolated value can not be restored. key:%s", packageName, key)
+		}
+		data = replaceValue(data, strings.Split(key, "."), original)
+	}
+
+	for key, original := range c.secrets {
+		data = replaceValue(data, strings.Split(key, "."), original)
+	}
+
+	if k == "" {
+		return mergeMap(nil, data.(map[string]interface{})), nil
+	}
+
+	val, exist := c.fetch(data.(map[string]interface{}), k)
+	if !exist {
+		return nil, ErrConfigNotExist
+	}
+
+	sub, ok := toStringMap(val)
+	if !ok {
+		return nil, fmt.Errorf("%s: value is not a map. key:%s", packageName, k)
+	}
+
+	return mergeMap(nil, sub), nil
+}
+
+// replaceValue replace the value of subkeys, return the copy of v
+//
+// slice elements are addressed by index, v is returned as it is when subkeys not exist.
+func replaceValue(v interface{}, subkeys []string, val interface{}) interface{} {
+	if len(subkeys) == 0 {
+		return val
+	}
+
+	if m, ok := toStringMap(v); ok {
+		sub, exist := m[subkeys[0]]
+		if !exist {
+			return v
+		}
+
+		m = copyMap(m)
+		m[subkeys[0]] = replaceValue(sub, subkeys[1:], val)
+		return m
+	}
+
+	if items, ok := v.([]interface{}); ok {
+		i, err := strconv.Atoi(subkeys[0])
+		if err != nil || i < 0 || i >= len(items) {
+			return v
+		}
+
+		ret := make([]interface{}, len(items))
+		copy(ret, items)
+		ret[i] = replaceValue(items[i], subkeys[1:], val)
+		return ret
+	}
+
+	return v
+}
+
+// writeFileAtomic write data into a temporary file and rename it to path
+//
+// the mode of the existing file is kept.
+func writeFileAtomic(path string, data []byte) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+
+	tmp := f.Name()
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmp)
+		}
+	}()
+
+	if _, err = f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	if err = os.Chmod(tmp, mode); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
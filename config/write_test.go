@@ -0,0 +1,330 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_configureImpl_Set(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []LoadOption
+		set     map[string]interface{}
+		update  string
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name: "set survive reload",
+			set:  map[string]interface{}{"a": 10, "b.c": "x"},
+			want: map[string]interface{}{"a": 10, "b": map[string]interface{}{"c": "x"}, "d": 3},
+		},
+		{
+			name: "discard set on reload",
+			opts: []LoadOption{WithDiscardSetOnReload()},
+			set:  map[string]interface{}{"a": 10},
+			want: map[string]interface{}{"a": 1, "d": 3},
+		},
+		{
+			name:   "reference to value set",
+			set:    map[string]interface{}{"b.c": "x"},
+			update: "a: ${b.c}\n",
+			want:   map[string]interface{}{"a": "x", "b": map[string]interface{}{"c": "x"}},
+		},
+		{
+			name: "validate fail",
+			opts: []LoadOption{WithReloadValidator(func(c Configure) error {
+				if c.GetInt("a", 0) > 5 {
+					return errors.New("a too large")
+				}
+				return nil
+			})},
+			set:     map[string]interface{}{"a": 10},
+			wantErr: true,
+		},
+		{
+			name:    "empty key",
+			set:     map[string]interface{}{"": 10},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "app.yaml")
+			writeFile(t, path, "a: 1\n")
+
+			c := defaultConfigure(path)
+			defer c.Close()
+			withTest()(c)
+			for _, opt := range tt.opts {
+				opt(c)
+			}
+
+			if err := c.Load(); err != nil {
+				t.Fatalf("configureImpl.Load() error = %v", err)
+			}
+
+			for k, v := range tt.set {
+				err := c.Set(k, v)
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("configureImpl.Set() error = %v, wantErr %v", err, tt.wantErr)
+				}
+			}
+
+			if tt.wantErr {
+				if got := c.GetInt("a", 0); got != 1 {
+					t.Errorf("configureImpl.GetInt() = %v, want 1", got)
+				}
+				return
+			}
+
+			update := tt.update
+			if update == "" {
+				update = "a: 1\nd: 3\n"
+			}
+			writeFile(t, path, update)
+			c.Reload()
+
+			got := map[string]interface{}{}
+			if err := c.Unmarshal(&got); err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("configureImpl.Unmarshal() = %v, error = %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func Test_configureImpl_Set_notify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	writeFile(t, path, "a: 1\n")
+
+	c := defaultConfigure(path)
+	defer c.Close()
+	withTest()(c)
+	if err := c.Load(); err != nil {
+		t.Fatalf("configureImpl.Load() error = %v", err)
+	}
+
+	var got []Change
+	c.Subscribe("", func(changes []Change) {
+		got = changes
+	})
+
+	if err := c.Sub("b").Set("c", 2); err != nil {
+		t.Fatalf("subConfigure.Set() error = %v", err)
+	}
+
+	want := []Change{{Key: "b.c", Type: ChangeAdded, NewValue: 2}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("changes = %v, want %v", got, want)
+	}
+}
+
+func Test_configureImpl_Set_withoutRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	writeFile(t, path, "a:\n  x: 1\nb:\n  - ${a.x}\n")
+
+	c := defaultConfigure(path)
+	defer c.Close()
+	withTest()(c)
+	if err := c.Load(); err != nil {
+		t.Fatalf("configureImpl.Load() error = %v", err)
+	}
+
+	// the file changed or removed is not read by set
+	writeFile(t, path, "a: 2\n")
+	if err := c.Set("c", 3); err != nil {
+		t.Fatalf("configureImpl.Set() error = %v", err)
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set("a.x", 4); err != nil {
+		t.Fatalf("configureImpl.Set() error = %v", err)
+	}
+
+	got := map[string]interface{}{}
+	want := map[string]interface{}{"a": map[string]interface{}{"x": 4}, "b": []interface{}{4}, "c": 3}
+	if err := c.Unmarshal(&got); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("configureImpl.Unmarshal() = %v, error = %v, want %v", got, err, want)
+	}
+
+	if origin := c.origins["c"]; origin == nil || origin.Type != OriginSet {
+		t.Errorf("configureImpl.Origin() = %v, want %v", origin, OriginSet)
+	}
+}
+
+func Test_configureImpl_WriteTo(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		file    string
+		format  string
+		want    string
+		wantErr error
+	}{
+		{
+			name: "convert to yaml",
+			file: "out.yaml",
+			want: "client:\n    name: app\n    password: ENC(...)\n    timeout: 500\n",
+		},
+		{
+			name:   "format assigned",
+			file:   "out",
+			format: "json",
+			want:   `{"client":{"name":"app","password":"ENC(...)","timeout":500}}`,
+		},
+		{
+			name: "sub tree",
+			key:  "client",
+			file: "out.toml",
+			want: "name = \"app\"\npassword = \"ENC(...)\"\ntimeout = 500\n",
+		},
+		{
+			name:    "unknown format",
+			file:    "out.unknown",
+			wantErr: ErrUnmarshalerNotExist,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "app.toml")
+			writeFile(t, path, "[client]\nname = 'app'\ntimeout = 500\n")
+
+			c := defaultConfigure(path)
+			defer c.Close()
+			withTest()(c)
+			if err := c.Load(); err != nil {
+				t.Fatalf("configureImpl.Load() error = %v", err)
+			}
+
+			// secret value is written as it was
+			c.secrets = map[string]interface{}{"client.password": "ENC(...)"}
+			c.unmarshaledData = mergeMap(c.unmarshaledData, map[string]interface{}{
+				"client": map[string]interface{}{"password": "plaintext"},
+			})
+
+			var configure Configure = c
+			if tt.key != "" {
+				configure = c.Sub(tt.key)
+			}
+
+			out := filepath.Join(dir, tt.file)
+			err := configure.WriteTo(out, tt.format)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("WriteTo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != nil {
+				return
+			}
+
+			got, err := ioutil.ReadFile(out)
+			if err != nil || string(got) != tt.want {
+				t.Errorf("WriteTo() = %q, error = %v, want %q", got, err, tt.want)
+			}
+
+			if c.GetString("client.password", "") != "plaintext" {
+				t.Errorf("WriteTo() modified the config")
+			}
+		})
+	}
+}
+
+func Test_configureImpl_WriteTo_interpolated(t *testing.T) {
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "pw")
+	writeFile(t, secretFile, "s3cr3t\n")
+
+	_ = os.Setenv("GO_PKG_CONFIG_TEST_DB_PASSWORD", "pa55")
+	defer os.Unsetenv("GO_PKG_CONFIG_TEST_DB_PASSWORD")
+
+	path := filepath.Join(dir, "app.yaml")
+	writeFile(t, path, "db:\n  secretfile: ${file:"+secretFile+"}\n  password: ${GO_PKG_CONFIG_TEST_DB_PASSWORD}\n"+
+		"  hosts: [a, '${GO_PKG_CONFIG_TEST_DB_PASSWORD}']\n  user: root\n  dsn: ${db.user}:${db.password}\n"+
+		"profiles:\n  prod:\n    db:\n      user: ${GO_PKG_CONFIG_TEST_DB_PASSWORD:-prod}\n")
+
+	c := defaultConfigure(path)
+	withTest()(c)
+	WithProfile("prod")(c)
+	if err := c.Load(); err != nil {
+		t.Fatalf("configureImpl.Load() error = %v", err)
+	}
+	defer c.Close()
+
+	if got := c.GetString("db.dsn", ""); got != "pa55:pa55" {
+		t.Fatalf("configureImpl.GetString() = %v, want pa55:pa55", got)
+	}
+
+	out := filepath.Join(dir, "out.yaml")
+	if err := c.Sub("db").WriteTo(out, ""); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+
+	want := "dsn: ${db.user}:${db.password}\nhosts:\n    - a\n    - ${GO_PKG_CONFIG_TEST_DB_PASSWORD}\n" +
+		"password: ${GO_PKG_CONFIG_TEST_DB_PASSWORD}\nsecretfile: ${file:" + secretFile + "}\n" +
+		"user: ${GO_PKG_CONFIG_TEST_DB_PASSWORD:-prod}\n"
+	got, err := ioutil.ReadFile(out)
+	if err != nil || string(got) != want {
+		t.Errorf("WriteTo() = %q, error = %v, want %q", got, err, want)
+	}
+
+	// value set at runtime overwrites the interpolated value
+	if err = c.Set("db.password", "plain"); err != nil {
+		t.Fatalf("configureImpl.Set() error = %v", err)
+	}
+	if err = c.WriteTo(out, ""); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	if got, _ = ioutil.ReadFile(out); !strings.Contains(string(got), "password: plain\n") {
+		t.Errorf("WriteTo() = %q, want the value set", got)
+	}
+}
+
+func Test_configureImpl_WriteTo_notRestorable(t *testing.T) {
+	_ = os.Setenv("GO_PKG_CONFIG_TEST_PORT", "8080")
+	defer os.Unsetenv("GO_PKG_CONFIG_TEST_PORT")
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.json")
+	writeFile(t, path, `{"port": ${GO_PKG_CONFIG_TEST_PORT}, "name": "app"}`)
+
+	c := defaultConfigure(path)
+	withTest()(c)
+	if err := c.Load(); err != nil {
+		t.Fatalf("configureImpl.Load() error = %v", err)
+	}
+	defer c.Close()
+
+	if err := c.WriteTo(filepath.Join(dir, "out.json"), ""); err == nil {
+		t.Errorf("WriteTo() error = nil, want interpolated value can not be restored")
+	}
+}
+
+func Test_replaceValue(t *testing.T) {
+	data := map[string]interface{}{
+		"a": []interface{}{map[string]interface{}{"b": "plaintext"}},
+	}
+
+	got := replaceValue(data, []string{"a", "0", "b"}, "ENC(...)")
+	want := map[string]interface{}{
+		"a": []interface{}{map[string]interface{}{"b": "ENC(...)"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("replaceValue() = %v, want %v", got, want)
+	}
+
+	if data["a"].([]interface{})[0].(map[string]interface{})["b"] != "plaintext" {
+		t.Errorf("replaceValue() modified the input")
+	}
+
+	if got = replaceValue(data, []string{"a", "1", "b"}, "x"); !reflect.DeepEqual(got, data) {
+		t.Errorf("replaceValue() = %v, want %v", got, data)
+	}
+}
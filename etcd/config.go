@@ -127,12 +127,13 @@ func registerClientConfig(c clientConfig) {
 }
 
 func getClientConfig(name string) clientConfig {
-	clientConfigRW.RLock()
-	defer clientConfigRW.RUnlock()
+	clientConfigRW.Lock()
+	defer clientConfigRW.Unlock()
 
 	c, exist := clientConfigMap[name]
 	if !exist {
-		clientConfigMap[name] = defaultClientConfig(name)
+		c = defaultClientConfig(name)
+		clientConfigMap[name] = c
 	}
 
 	return c
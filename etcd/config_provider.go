@@ -0,0 +1,168 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/wwwangxc/go-pkg/config"
+	"github.com/wwwangxc/go-pkg/config/unmarshaler"
+)
+
+const defaultConfigProviderTimeout = 3 * time.Second
+
+// ConfigProviderOption config provider option
+type ConfigProviderOption func(*configProvider)
+
+// WithConfigPrefix read all keys with the key prefix instead of a single key
+//
+// keys are mapped onto dotted keys by '/', e.g. with prefix /app/config/,
+// /app/config/client/redis/max_idle will be mapped to client.redis.max_idle.
+// values are unmarshaled by the unmarshaler of the config, and kept as string when fail.
+func WithConfigPrefix() ConfigProviderOption {
+	return func(p *configProvider) {
+		p.prefix = true
+	}
+}
+
+// WithConfigTimeout set timeout of reading config
+//
+// Default 3s
+func WithConfigTimeout(timeout time.Duration) ConfigProviderOption {
+	return func(p *configProvider) {
+		p.timeout = timeout
+	}
+}
+
+// NewConfigProvider new config provider which reads config document from etcd key
+//
+// config is reloaded when the key changed.
+//
+//	configure, err := config.LoadProvider(etcd.NewConfigProvider(etcd.NewClientProxy("etcd1"), "/app/app.yaml"))
+func NewConfigProvider(proxy ClientProxy, key string, opts ...ConfigProviderOption) config.Provider {
+	p := &configProvider{
+		proxy:   proxy,
+		key:     key,
+		timeout: defaultConfigProviderTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+type configProvider struct {
+	proxy   ClientProxy
+	key     string
+	prefix  bool
+	timeout time.Duration
+}
+
+// Name provider name
+func (p *configProvider) Name() string {
+	if p.prefix {
+		return fmt.Sprintf("etcd:%s*", p.key)
+	}
+
+	return fmt.Sprintf("etcd:%s", p.key)
+}
+
+// Read read config from etcd
+func (p *configProvider) Read(u unmarshaler.Unmarshaler) (map[string]interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	if p.prefix {
+		return p.readPrefix(ctx, u)
+	}
+
+	resp, err := p.proxy.Get(ctx, p.key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Kvs) == 0 {
+		return nil, fmt.Errorf("key not exist. key:%s", p.key)
+	}
+
+	data := map[string]interface{}{}
+	if err = u.Unmarshal(resp.Kvs[0].Value, &data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (p *configProvider) readPrefix(ctx context.Context, u unmarshaler.Unmarshaler) (map[string]interface{}, error) {
+	resp, err := p.proxy.Get(ctx, p.key, clientv3.WithPrefix(), clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend))
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string]interface{}{}
+	for _, kv := range resp.Kvs {
+		subkeys := strings.Split(strings.Trim(strings.TrimPrefix(string(kv.Key), p.key), "/"), "/")
+		if len(subkeys) == 0 || subkeys[0] == "" {
+			continue
+		}
+
+		var val interface{}
+		if err = u.Unmarshal(kv.Value, &val); err != nil || val == nil {
+			val = string(kv.Value)
+		}
+
+		setToMap(data, subkeys, val)
+	}
+
+	return data, nil
+}
+
+// Watch watch the key or prefix, block until ctx done
+func (p *configProvider) Watch(ctx context.Context, onChange func()) error {
+	var opts []clientv3.OpOption
+	if p.prefix {
+		opts = append(opts, clientv3.WithPrefix())
+	}
+
+	wch, err := p.proxy.Watch(clientv3.WithRequireLeader(ctx), p.key, opts...)
+	if err != nil {
+		return err
+	}
+
+	for resp := range wch {
+		if err = resp.Err(); err != nil {
+			return err
+		}
+
+		if len(resp.Events) > 0 {
+			onChange()
+		}
+	}
+
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	return fmt.Errorf("watch channel closed. key:%s", p.key)
+}
+
+// setToMap set value into nested map by subkeys, missing sub maps will be created
+func setToMap(m map[string]interface{}, subkeys []string, val interface{}) {
+	if len(subkeys) == 1 {
+		m[subkeys[0]] = val
+		return
+	}
+
+	sub, ok := m[subkeys[0]].(map[string]interface{})
+	if !ok {
+		sub = map[string]interface{}{}
+		m[subkeys[0]] = sub
+	}
+
+	setToMap(sub, subkeys[1:], val)
+}
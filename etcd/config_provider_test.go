@@ -0,0 +1,320 @@
+package etcd_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/embed"
+
+	"github.com/wwwangxc/go-pkg/config"
+	"github.com/wwwangxc/go-pkg/config/unmarshaler"
+	"github.com/wwwangxc/go-pkg/etcd"
+)
+
+func TestConfigProvider_Read(t *testing.T) {
+	_, endpoints := startETCD(t, 1)
+	proxy := etcd.NewClientProxy(t.Name(), etcd.WithTarget(endpoints))
+
+	// put in reverse order, the prefix keys are read in ascending order
+	kvs := [][2]string{
+		{"/app/config/debug", "true"},
+		{"/app/config/client/redis/max_idle", "10"},
+		{"/app/config/client/redis/dsn", "redis://127.0.0.1:6379/0"},
+		{"/app/config/client", "replaced by sub keys"},
+		{"/app/app.yaml", "client:\n  timeout: 1000\n"},
+	}
+	for _, kv := range kvs {
+		if _, err := proxy.Put(context.Background(), kv[0], kv[1]); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		opts    []etcd.ConfigProviderOption
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name:    "key not exist",
+			key:     "/app/not_exist.yaml",
+			wantErr: true,
+		},
+		{
+			name: "single key",
+			key:  "/app/app.yaml",
+			want: map[string]interface{}{
+				"client": map[string]interface{}{"timeout": 1000},
+			},
+		},
+		{
+			name: "prefix",
+			key:  "/app/config/",
+			opts: []etcd.ConfigProviderOption{etcd.WithConfigPrefix()},
+			want: map[string]interface{}{
+				"client": map[string]interface{}{
+					"redis": map[string]interface{}{
+						"max_idle": 10,
+						"dsn":      "redis://127.0.0.1:6379/0",
+					},
+				},
+				"debug": true,
+			},
+		},
+		{
+			name: "prefix not exist",
+			key:  "/other/",
+			opts: []etcd.ConfigProviderOption{etcd.WithConfigPrefix()},
+			want: map[string]interface{}{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := etcd.NewConfigProvider(proxy, tt.key, tt.opts...)
+			got, err := p.Read(unmarshaler.Get("yaml"))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Read() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Read() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigProvider_Watch(t *testing.T) {
+	_, endpoints := startETCD(t, 1)
+	proxy := etcd.NewClientProxy(t.Name(), etcd.WithTarget(endpoints))
+
+	tests := []struct {
+		name    string
+		key     string
+		opts    []etcd.ConfigProviderOption
+		ignored string
+		changed string
+	}{
+		{
+			name:    "single key",
+			key:     "/app/app.yaml",
+			ignored: "/app/app.yaml.bak",
+			changed: "/app/app.yaml",
+		},
+		{
+			name:    "prefix",
+			key:     "/app/config/",
+			opts:    []etcd.ConfigProviderOption{etcd.WithConfigPrefix()},
+			ignored: "/app/other",
+			changed: "/app/config/client/redis/max_idle",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			changed := make(chan struct{}, 10)
+			errCh := make(chan error, 1)
+			go func() {
+				p := etcd.NewConfigProvider(proxy, tt.key, tt.opts...)
+				errCh <- p.Watch(ctx, func() { changed <- struct{}{} })
+			}()
+
+			// wait for the watch to be established
+			time.Sleep(100 * time.Millisecond)
+
+			if _, err := proxy.Put(context.Background(), tt.ignored, "1"); err != nil {
+				t.Fatalf("Put() error = %v", err)
+			}
+			if _, err := proxy.Put(context.Background(), tt.changed, "1"); err != nil {
+				t.Fatalf("Put() error = %v", err)
+			}
+			waitChanged(t, changed)
+
+			if _, err := proxy.Delete(context.Background(), tt.changed); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			waitChanged(t, changed)
+
+			select {
+			case <-changed:
+				t.Errorf("Watch() onChange called by key %s", tt.ignored)
+			case <-time.After(100 * time.Millisecond):
+			}
+
+			cancel()
+			if err := <-errCh; err != nil {
+				t.Errorf("Watch() error = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestConfigProvider_Watch_clientClosed(t *testing.T) {
+	_, endpoints := startETCD(t, 1)
+	cli, err := clientv3.New(clientv3.Config{Endpoints: []string{endpoints}, DialTimeout: time.Second})
+	if err != nil {
+		t.Fatalf("clientv3.New() error = %v", err)
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		p := etcd.NewConfigProvider(&watchProxy{cli: cli}, "/app/app.yaml")
+		errCh <- p.Watch(context.Background(), func() {})
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	_ = cli.Close()
+
+	select {
+	case err = <-errCh:
+		if err == nil {
+			t.Errorf("Watch() error = nil, want watch channel closed error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Watch() not return after client closed")
+	}
+}
+
+func TestConfigProvider_Watch_requireLeader(t *testing.T) {
+	members, _ := startETCD(t, 3)
+	proxy := etcd.NewClientProxy(t.Name(), etcd.WithTarget(members[0].Clients[0].Addr().String()))
+
+	errCh := make(chan error, 1)
+	go func() {
+		p := etcd.NewConfigProvider(proxy, "/app/app.yaml")
+		errCh <- p.Watch(context.Background(), func() {})
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+
+	// the member lost the quorum
+	members[1].Close()
+	members[2].Close()
+
+	select {
+	case err := <-errCh:
+		if err == nil || !strings.Contains(err.Error(), "no leader") {
+			t.Errorf("Watch() error = %v, want no leader error", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Watch() not return after leader lost")
+	}
+}
+
+func TestConfigProvider_LoadProvider(t *testing.T) {
+	_, endpoints := startETCD(t, 1)
+	proxy := etcd.NewClientProxy(t.Name(), etcd.WithTarget(endpoints))
+	if _, err := proxy.Put(context.Background(), "/app/app.yaml", "client:\n  max_idle: 1\n"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	c, err := config.LoadProvider(etcd.NewConfigProvider(proxy, "/app/app.yaml"), config.WithUnmarshaler("yaml"))
+	if err != nil {
+		t.Fatalf("LoadProvider() error = %v", err)
+	}
+	defer c.Close()
+
+	if got := c.GetInt("client.max_idle", 0); got != 1 {
+		t.Errorf("GetInt() = %v, want %v", got, 1)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if _, err = proxy.Put(context.Background(), "/app/app.yaml", "client:\n  max_idle: 2\n"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	for i := 0; i < 100 && c.GetInt("client.max_idle", 0) != 2; i++ {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if got := c.GetInt("client.max_idle", 0); got != 2 {
+		t.Errorf("GetInt() = %v, want %v", got, 2)
+	}
+}
+
+// startETCD start embedded etcd cluster with n members, return the members and client endpoints
+func startETCD(t *testing.T, n int) ([]*embed.Etcd, string) {
+	var cfgs []*embed.Config
+	var initialCluster, endpoints []string
+	for i := 0; i < n; i++ {
+		clientURL, peerURL := localURL(t), localURL(t)
+
+		cfg := embed.NewConfig()
+		cfg.Name = fmt.Sprintf("member%d", i)
+		cfg.Dir = t.TempDir()
+		cfg.LogLevel = "error"
+		cfg.TickMs = 10
+		cfg.ElectionMs = 100
+		cfg.InitialClusterToken = t.Name()
+		cfg.LCUrls, cfg.ACUrls = []url.URL{clientURL}, []url.URL{clientURL}
+		cfg.LPUrls, cfg.APUrls = []url.URL{peerURL}, []url.URL{peerURL}
+
+		cfgs = append(cfgs, cfg)
+		initialCluster = append(initialCluster, fmt.Sprintf("%s=%s", cfg.Name, peerURL.String()))
+		endpoints = append(endpoints, clientURL.Host)
+	}
+
+	var members []*embed.Etcd
+	for _, cfg := range cfgs {
+		cfg.InitialCluster = strings.Join(initialCluster, ",")
+		e, err := embed.StartEtcd(cfg)
+		if err != nil {
+			t.Fatalf("StartEtcd() error = %v", err)
+		}
+
+		t.Cleanup(func() {
+			select {
+			case <-e.Server.StopNotify():
+			default:
+				e.Close()
+			}
+		})
+		members = append(members, e)
+	}
+
+	for _, e := range members {
+		select {
+		case <-e.Server.ReadyNotify():
+		case <-time.After(10 * time.Second):
+			t.Fatal("embedded etcd not ready")
+		}
+	}
+
+	return members, strings.Join(endpoints, ",")
+}
+
+// localURL url of a free local port
+func localURL(t *testing.T) url.URL {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	return url.URL{Scheme: "http", Host: l.Addr().String()}
+}
+
+// watchProxy watch by the client, which can be closed by test
+type watchProxy struct {
+	etcd.ClientProxy
+	cli *clientv3.Client
+}
+
+func (w *watchProxy) Watch(ctx context.Context, key string, opts ...clientv3.OpOption) (clientv3.WatchChan, error) {
+	return w.cli.Watch(ctx, key, opts...), nil
+}
+
+func waitChanged(t *testing.T, changed <-chan struct{}) {
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Fatal("Watch() onChange not called")
+	}
+}
@@ -24,7 +24,7 @@ func getETCDClient(name string, opts ...ClientOption) (*clientv3.Client, error)
 		opt(&cfg)
 	}
 
-	return newETCDClient(getClientConfig(name))
+	return newETCDClient(cfg)
 }
 
 func newETCDClient(cfg clientConfig) (*clientv3.Client, error) {
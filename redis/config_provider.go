@@ -0,0 +1,216 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	redigo "github.com/gomodule/redigo/redis"
+
+	"github.com/wwwangxc/go-pkg/config"
+	"github.com/wwwangxc/go-pkg/config/unmarshaler"
+)
+
+const defaultConfigProviderTimeout = 3 * time.Second
+
+// ConfigProviderOption config provider option
+type ConfigProviderOption func(*configProvider)
+
+// WithConfigHash read config from hash instead of string key
+//
+// fields are mapped onto dotted keys, e.g. field client.redis.max_idle.
+// values are unmarshaled by the unmarshaler of the config, and kept as string when fail.
+func WithConfigHash() ConfigProviderOption {
+	return func(p *configProvider) {
+		p.hash = true
+	}
+}
+
+// WithConfigChannel reload config when message published on the channel
+func WithConfigChannel(channel string) ConfigProviderOption {
+	return func(p *configProvider) {
+		p.channel = channel
+	}
+}
+
+// WithConfigKeyspaceNotify reload config by keyspace notifications of the key
+//
+// keyspace notifications must be enabled on the server, e.g. CONFIG SET notify-keyspace-events Kgh$
+func WithConfigKeyspaceNotify(db int) ConfigProviderOption {
+	return func(p *configProvider) {
+		p.channel = ""
+		p.keyspaceDB = &db
+	}
+}
+
+// WithConfigTimeout set timeout of reading config
+//
+// Default 3s
+func WithConfigTimeout(timeout time.Duration) ConfigProviderOption {
+	return func(p *configProvider) {
+		p.timeout = timeout
+	}
+}
+
+// NewConfigProvider new config provider which reads config document from redis key
+//
+// config is reloaded when message published on the channel assigned by WithConfigChannel,
+// or keyspace notification of the key when WithConfigKeyspaceNotify.
+// config will not be reloaded when neither assigned.
+//
+//	configure, err := config.LoadProvider(redis.NewConfigProvider(redis.NewClientProxy("redis1"), "app.yaml",
+//		redis.WithConfigChannel("app.yaml.changed")))
+func NewConfigProvider(proxy ClientProxy, key string, opts ...ConfigProviderOption) config.Provider {
+	p := &configProvider{
+		proxy:   proxy,
+		key:     key,
+		timeout: defaultConfigProviderTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+type configProvider struct {
+	proxy      ClientProxy
+	key        string
+	hash       bool
+	channel    string
+	keyspaceDB *int
+	timeout    time.Duration
+}
+
+// Name provider name
+func (p *configProvider) Name() string {
+	return fmt.Sprintf("redis:%s", p.key)
+}
+
+// Read read config from redis
+func (p *configProvider) Read(u unmarshaler.Unmarshaler) (map[string]interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	if p.hash {
+		return p.readHash(ctx, u)
+	}
+
+	value, err := Bytes(p.proxy.Do(ctx, "GET", p.key))
+	if errors.Is(err, redigo.ErrNil) {
+		return nil, fmt.Errorf("key not exist. key:%s", p.key)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string]interface{}{}
+	if err = u.Unmarshal(value, &data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (p *configProvider) readHash(ctx context.Context, u unmarshaler.Unmarshaler) (map[string]interface{}, error) {
+	fields, err := StringMap(p.proxy.Do(ctx, "HGETALL", p.key))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("key not exist. key:%s", p.key)
+	}
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	data := map[string]interface{}{}
+	for _, k := range keys {
+		var val interface{}
+		if err = u.Unmarshal([]byte(fields[k]), &val); err != nil || val == nil {
+			val = fields[k]
+		}
+
+		setToMap(data, strings.Split(k, "."), val)
+	}
+
+	return data, nil
+}
+
+// Watch subscribe the channel, block until ctx done
+func (p *configProvider) Watch(ctx context.Context, onChange func()) error {
+	channel := p.channel
+	if p.keyspaceDB != nil {
+		channel = fmt.Sprintf("__keyspace@%d__:%s", *p.keyspaceDB, p.key)
+	}
+
+	if channel == "" {
+		<-ctx.Done()
+		return nil
+	}
+
+	psc := redigo.PubSubConn{Conn: p.proxy.GetConn()}
+	defer func() {
+		if err := psc.Close(); err != nil {
+			logErrorf("connect close fail. error:%v", err)
+		}
+	}()
+
+	if err := psc.Subscribe(channel); err != nil {
+		return err
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		for {
+			switch v := psc.ReceiveWithTimeout(0).(type) {
+			case redigo.Message:
+				onChange()
+			case redigo.Subscription:
+				if v.Count == 0 {
+					errCh <- nil
+					return
+				}
+			case error:
+				errCh <- v
+				return
+			}
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+		if err := psc.Unsubscribe(); err != nil {
+			return nil
+		}
+		<-errCh
+		return nil
+	case err := <-errCh:
+		return err
+	}
+}
+
+// setToMap set value into nested map by subkeys, missing sub maps will be created
+func setToMap(m map[string]interface{}, subkeys []string, val interface{}) {
+	if len(subkeys) == 1 {
+		m[subkeys[0]] = val
+		return
+	}
+
+	sub, ok := m[subkeys[0]].(map[string]interface{})
+	if !ok {
+		sub = map[string]interface{}{}
+		m[subkeys[0]] = sub
+	}
+
+	setToMap(sub, subkeys[1:], val)
+}
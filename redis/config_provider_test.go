@@ -0,0 +1,113 @@
+package redis
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+
+	"github.com/wwwangxc/go-pkg/config"
+	"github.com/wwwangxc/go-pkg/config/unmarshaler"
+)
+
+func Test_configProvider_Read(t *testing.T) {
+	mr := miniredis.RunT(t)
+	mr.Set("app.yaml", "client:\n  timeout: 1000\n")
+	mr.HSet("app", "client.redis.max_idle", "10", "client.redis.dsn", "redis://127.0.0.1:6379/0", "debug", "true")
+	proxy := NewClientProxy("config_provider_read"+mr.Addr(), WithClientDSN("redis://"+mr.Addr()))
+
+	tests := []struct {
+		name    string
+		key     string
+		opts    []ConfigProviderOption
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name:    "key not exist",
+			key:     "not_exist",
+			wantErr: true,
+		},
+		{
+			name:    "hash not exist",
+			key:     "not_exist",
+			opts:    []ConfigProviderOption{WithConfigHash()},
+			wantErr: true,
+		},
+		{
+			name: "string key",
+			key:  "app.yaml",
+			want: map[string]interface{}{
+				"client": map[string]interface{}{"timeout": 1000},
+			},
+		},
+		{
+			name: "hash",
+			key:  "app",
+			opts: []ConfigProviderOption{WithConfigHash()},
+			want: map[string]interface{}{
+				"client": map[string]interface{}{
+					"redis": map[string]interface{}{
+						"max_idle": 10,
+						"dsn":      "redis://127.0.0.1:6379/0",
+					},
+				},
+				"debug": true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewConfigProvider(proxy, tt.key, tt.opts...)
+			got, err := p.Read(unmarshaler.Get("yaml"))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Read() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Read() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_configProvider_Watch(t *testing.T) {
+	mr := miniredis.RunT(t)
+	mr.Set("app.yaml", "client:\n  timeout: 1000\n")
+	proxy := NewClientProxy("config_provider_watch"+mr.Addr(), WithClientDSN("redis://"+mr.Addr()))
+
+	called := make(chan struct{}, 1)
+	configure, err := config.LoadProvider(NewConfigProvider(proxy, "app.yaml", WithConfigChannel("app.yaml.changed")),
+		config.WithWatchCallback(func(config.Configure) { called <- struct{}{} }))
+	if err != nil {
+		t.Fatalf("LoadProvider() error = %v", err)
+	}
+	defer configure.Close()
+
+	if got := configure.GetInt("client.timeout", 0); got != 1000 {
+		t.Fatalf("GetInt() got = %v, want 1000", got)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for mr.PubSubNumSub("app.yaml.changed")["app.yaml.changed"] == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("channel not subscribed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mr.Set("app.yaml", "client:\n  timeout: 2000\n")
+	mr.Publish("app.yaml.changed", "")
+
+	select {
+	case <-called:
+	case <-time.After(time.Second):
+		t.Fatal("watch callback not called")
+	}
+
+	if got := configure.GetInt("client.timeout", 0); got != 2000 {
+		t.Errorf("GetInt() got = %v, want 2000", got)
+	}
+}